@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vjranagit/jaeger-toolkit/pkg/analysis"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+)
+
+func newAnalyzeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "analyze",
+		Short: "Analyze traces exported as JSON",
+	}
+
+	var format string
+	var segments bool
+	var top int
+	criticalPath := &cobra.Command{
+		Use:   "critical-path <traces.json|->",
+		Short: "Show which spans determined each trace's latency",
+		Long: `Computes the critical path of each trace in a JSON file (or stdin with "-"):
+the chain of span segments that determined end-to-end latency, accounting for
+concurrent children. Spans are listed by their critical time.
+
+Both the native format and jaeger-query /api/traces responses (as downloaded
+from the Jaeger UI) are read; the format is detected unless --format is set.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return analyzeCriticalPath(cmd.OutOrStdout(), cmd.ErrOrStderr(), args[0], model.JSONFormat(format), segments, top)
+		},
+	}
+	criticalPath.Flags().StringVar(&format, "format", "", "input format: native or jaeger-ui (default: detect)")
+	criticalPath.Flags().BoolVar(&segments, "segments", false, "also list the path's segments in order")
+	criticalPath.Flags().IntVar(&top, "top", 0, "only list the N spans with the most critical time")
+
+	cmd.AddCommand(criticalPath)
+	return cmd
+}
+
+// analyzeCriticalPath prints the critical path of every trace in the
+// file. Traces that cannot be analysed (empty or null) are reported on
+// errW and skipped.
+func analyzeCriticalPath(w, errW io.Writer, path string, format model.JSONFormat, segments bool, top int) error {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	if format == "" {
+		// jaeger-query responses are objects, native files arrays
+		format = model.JSONNative
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+			format = model.JSONJaegerUI
+		}
+	}
+	traces, err := model.UnmarshalTraces(data, format, false)
+	if err != nil {
+		return fmt.Errorf("failed to read traces from %s: %w", path, err)
+	}
+
+	printed, failed := 0, 0
+	for i, trace := range traces {
+		cp, err := analysis.ComputeCriticalPath(trace)
+		if err != nil {
+			fmt.Fprintf(errW, "Warning: skipping trace %d: %v\n", i+1, err)
+			failed++
+			continue
+		}
+		if printed > 0 {
+			fmt.Fprintln(w)
+		}
+		printCriticalPath(w, trace, cp, segments, top)
+		printed++
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d traces in %s could not be analysed", failed, len(traces), path)
+	}
+	return nil
+}
+
+func printCriticalPath(w io.Writer, trace *model.Trace, cp *analysis.CriticalPath, segments bool, top int) {
+	fmt.Fprintf(w, "Trace %s: %v end to end, %v on the critical path\n\n",
+		trace.TraceID, cp.End.Sub(cp.Start), cp.Duration())
+
+	contributions := cp.Contributions()
+	if top > 0 && top < len(contributions) {
+		contributions = contributions[:top]
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CRITICAL\tSHARE\tSERVICE\tOPERATION\tSPAN")
+	for _, c := range contributions {
+		fmt.Fprintf(tw, "%v\t%.1f%%\t%s\t%s\t%s\n",
+			c.Duration, c.Percent, serviceName(c.Span), c.Span.OperationName, c.Span.SpanID)
+	}
+	tw.Flush()
+
+	if segments {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "OFFSET\tDURATION\tSERVICE\tOPERATION\tSPAN")
+		for _, s := range cp.Segments {
+			fmt.Fprintf(tw, "+%v\t%v\t%s\t%s\t%s\n",
+				s.Start.Sub(cp.Start).Round(time.Microsecond), s.Duration(), serviceName(s.Span), s.Span.OperationName, s.Span.SpanID)
+		}
+		tw.Flush()
+	}
+}
+
+func serviceName(span *model.Span) string {
+	if span.Process == nil {
+		return "-"
+	}
+	return span.Process.ServiceName
+}
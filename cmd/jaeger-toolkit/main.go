@@ -33,6 +33,7 @@ Features:
 	rootCmd.AddCommand(
 		newPipelineCmd(),
 		newDeployCmd(),
+		newAnalyzeCmd(),
 		newVersionCmd(),
 	)
 
@@ -10,6 +10,25 @@ receiver "otlp" "main" {
   }
 }
 
+# Size limits against pathological payloads
+# Runs first so nothing downstream sees oversized spans
+processor "limits" "guard" {
+  max_tags = 128
+  max_logs = 128
+  max_string_length = 4096
+  max_binary_length = 4096
+  max_spans_per_trace = 10000
+}
+
+# RED metrics per service/operation/status
+# Must run before sampling so dashboards see the full traffic
+processor "spanmetrics" "red" {
+  buckets = ["10ms", "50ms", "100ms", "250ms", "500ms", "1s", "5s"]
+  max_series = 10000
+  flush_interval = "15s"
+  path = "/metrics/spans"
+}
+
 # Adaptive sampling processor
 # Intelligently samples spans based on errors and latency
 processor "sampling" "adaptive" {
@@ -22,14 +41,22 @@ processor "sampling" "adaptive" {
   # Always keep slow requests (>500ms)
   slow_threshold = "500ms"
   
-  # Adapt sampling rate over 1000 spans
-  adaptive_window = 1000
+  # Feedback controller for the probabilistic rate
+  adaptive {
+    target_spans_per_second = 500  # Probabilistic output budget
+    target_error_share = 0.01      # Boost above 1% errors
+    window = "10s"
+    smoothing = 0.3
+    min_sample_rate = 0.001
+    max_sample_rate = 1.0
+  }
 }
 
 # Batch processor for efficient export
 processor "batch" "default" {
   timeout = "1s"
   send_batch_size = 1024
+  send_batch_max_bytes = 4194304  # Stay under the collector's 4MiB gRPC limit
 }
 
 exporter "jaeger" "backend" {
@@ -48,6 +75,8 @@ pipeline "traces" {
   
   # Apply adaptive sampling before batching
   processors = [
+    processor.limits.guard,
+    processor.spanmetrics.red,
     processor.sampling.adaptive,
     processor.batch.default
   ]
@@ -26,6 +26,43 @@ processor "attributes" "enrich" {
     value = "1.0.0"
     action = "upsert"
   }
+  action {
+    key = "sampling.priority"
+    value = "1"
+    type = "int64"
+    action = "insert"
+  }
+  action {
+    key_glob = "user.*"
+    action = "hash"
+  }
+  action {
+    key = "http.url"
+    pattern = "^https?://(?P<http_host>[^/]+)/"
+    action = "extract"
+  }
+  action {
+    key = "http.status_code"
+    converted_type = "int64"
+    action = "convert"
+  }
+  action {
+    key_regex = "^internal\\."
+    action = "delete"
+  }
+}
+
+processor "filter" "noise" {
+  rule "healthcheck" {
+    operation_regex = "^GET /(health|ready)$"
+  }
+  rule "scrapes" {
+    tags = { "http.target" = "/metrics" }
+  }
+  rule "synthetic" {
+    expression = "tags[\"synthetic\"] == true"
+    drop_trace = true
+  }
 }
 
 exporter "jaeger" "backend" {
@@ -38,6 +75,7 @@ exporter "jaeger" "backend" {
 pipeline "traces" {
   receivers = ["receiver.otlp.main"]
   processors = [
+    "processor.filter.noise",
     "processor.batch.default",
     "processor.attributes.enrich"
   ]
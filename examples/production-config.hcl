@@ -19,7 +19,11 @@ processor "sampling" "head" {
   base_sample_rate = 0.01
   always_sample_errors = true
   slow_threshold = "1s"
-  adaptive_window = 10000
+
+  adaptive {
+    window = "30s"
+    target_error_share = 0.01
+  }
 }
 
 processor "sampling" "tail" {
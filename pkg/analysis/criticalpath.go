@@ -0,0 +1,175 @@
+// Package analysis answers questions about complete traces, such as
+// which spans determined a request's latency.
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+)
+
+// Segment is a stretch of the critical path spent in one span, not
+// waiting on any of its children
+type Segment struct {
+	Span  *model.Span
+	Start time.Time
+	End   time.Time
+}
+
+// Duration returns the length of the segment
+func (s Segment) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Contribution is the total critical time of one span
+type Contribution struct {
+	Span     *model.Span
+	Duration time.Duration
+	Percent  float64 // Share of the critical path's total
+}
+
+// CriticalPath is the chain of span segments that determined a trace's
+// end-to-end latency
+type CriticalPath struct {
+	Segments []Segment // In chronological order
+	Start    time.Time
+	End      time.Time
+}
+
+// Duration returns the time covered by the critical path
+func (p *CriticalPath) Duration() time.Duration {
+	total := time.Duration(0)
+	for _, segment := range p.Segments {
+		total += segment.Duration()
+	}
+	return total
+}
+
+// Contributions returns the critical time of each span on the path,
+// largest first
+func (p *CriticalPath) Contributions() []Contribution {
+	index := make(map[*model.Span]int)
+	var contributions []Contribution
+	for _, segment := range p.Segments {
+		i, ok := index[segment.Span]
+		if !ok {
+			i = len(contributions)
+			index[segment.Span] = i
+			contributions = append(contributions, Contribution{Span: segment.Span})
+		}
+		contributions[i].Duration += segment.Duration()
+	}
+
+	total := p.Duration()
+	for i := range contributions {
+		if total > 0 {
+			contributions[i].Percent = 100 * float64(contributions[i].Duration) / float64(total)
+		}
+	}
+	// Stable, so equal contributions keep their order along the path
+	sort.SliceStable(contributions, func(i, j int) bool {
+		return contributions[i].Duration > contributions[j].Duration
+	})
+	return contributions
+}
+
+// ComputeCriticalPath computes the critical path of a trace.
+//
+// Starting at the end of the root span, the path steps back into the
+// child that finished last, then into the child that finished last
+// before that child started, and so on; the time in between belongs to
+// the parent. Children are clipped to their parent's interval, so work
+// continuing after the parent returned is not on the path. A trace with
+// several top-level spans (roots or orphans) is analysed as if they
+// shared a parent spanning all of them, with the gaps left out.
+func ComputeCriticalPath(trace *model.Trace) (*CriticalPath, error) {
+	if trace == nil {
+		return nil, fmt.Errorf("trace is null")
+	}
+	tree := model.NewSpanTree(trace)
+	top := append(append([]*model.SpanNode(nil), tree.Roots...), tree.Orphans...)
+	if len(top) == 0 {
+		return nil, fmt.Errorf("trace %s has no spans", trace.TraceID)
+	}
+
+	path := &CriticalPath{Start: top[0].Span.StartTime, End: spanEnd(top[0].Span)}
+	for _, node := range top[1:] {
+		if node.Span.StartTime.Before(path.Start) {
+			path.Start = node.Span.StartTime
+		}
+		if end := spanEnd(node.Span); end.After(path.End) {
+			path.End = end
+		}
+	}
+
+	var reversed []Segment
+	if len(top) == 1 {
+		walkCriticalPath(top[0], path.Start, path.End, &reversed)
+	} else {
+		walkChildren(nil, top, path.Start, path.End, &reversed)
+	}
+
+	path.Segments = make([]Segment, len(reversed))
+	for i, segment := range reversed {
+		path.Segments[len(reversed)-1-i] = segment
+	}
+	return path, nil
+}
+
+// walkCriticalPath appends the path through a node within [start, end]
+// to segments, latest first
+func walkCriticalPath(node *model.SpanNode, start, end time.Time, segments *[]Segment) {
+	walkChildren(node.Span, node.Children, start, end, segments)
+}
+
+// walkChildren walks back from end through the children, attributing
+// time not spent in a child to span (dropped if span is nil)
+func walkChildren(span *model.Span, children []*model.SpanNode, start, end time.Time, segments *[]Segment) {
+	type window struct {
+		node       *model.SpanNode
+		start, end time.Time
+	}
+	var windows []window
+	for _, child := range children {
+		s, e := child.Span.StartTime, spanEnd(child.Span)
+		if s.Before(start) {
+			s = start
+		}
+		if e.After(end) {
+			e = end
+		}
+		if e.After(s) {
+			windows = append(windows, window{child, s, e})
+		}
+	}
+	// Latest finishing first; among equal ends, the earliest starting
+	sort.SliceStable(windows, func(i, j int) bool {
+		if !windows[i].end.Equal(windows[j].end) {
+			return windows[i].end.After(windows[j].end)
+		}
+		return windows[i].start.Before(windows[j].start)
+	})
+
+	cursor := end
+	for _, w := range windows {
+		if w.end.After(cursor) {
+			continue // Overlaps the child already on the path
+		}
+		appendSegment(segments, span, w.end, cursor)
+		walkCriticalPath(w.node, w.start, w.end, segments)
+		cursor = w.start
+	}
+	appendSegment(segments, span, start, cursor)
+}
+
+func appendSegment(segments *[]Segment, span *model.Span, start, end time.Time) {
+	if span != nil && end.After(start) {
+		*segments = append(*segments, Segment{Span: span, Start: start, End: end})
+	}
+}
+
+func spanEnd(span *model.Span) time.Time {
+	return span.StartTime.Add(span.Duration)
+}
@@ -0,0 +1,135 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+)
+
+// pathSpan builds a span starting at a millisecond offset
+func pathSpan(id, parent model.SpanID, startMs, durationMs int) *model.Span {
+	return &model.Span{
+		TraceID:      model.TraceID{Low: 1},
+		SpanID:       id,
+		ParentSpanID: parent,
+		StartTime:    time.Unix(0, 0).Add(time.Duration(startMs) * time.Millisecond),
+		Duration:     time.Duration(durationMs) * time.Millisecond,
+	}
+}
+
+type segmentMs struct {
+	span       model.SpanID
+	start, end int
+}
+
+func segmentsMs(path *CriticalPath) []segmentMs {
+	var out []segmentMs
+	for _, s := range path.Segments {
+		out = append(out, segmentMs{
+			span:  s.Span.SpanID,
+			start: int(s.Start.Sub(time.Unix(0, 0)) / time.Millisecond),
+			end:   int(s.End.Sub(time.Unix(0, 0)) / time.Millisecond),
+		})
+	}
+	return out
+}
+
+func TestCriticalPathConcurrentChildren(t *testing.T) {
+	// 1: 0-100
+	//   2: 10-40
+	//     4: 15-35
+	//   3: 20-70 (concurrent with 2, finishes last)
+	//   5: 75-90
+	trace := &model.Trace{Spans: []*model.Span{
+		pathSpan(1, 0, 0, 100),
+		pathSpan(2, 1, 10, 30),
+		pathSpan(3, 1, 20, 50),
+		pathSpan(4, 2, 15, 20),
+		pathSpan(5, 1, 75, 15),
+	}}
+
+	path, err := ComputeCriticalPath(trace)
+	require.NoError(t, err)
+	assert.Equal(t, []segmentMs{
+		{1, 0, 20},
+		{3, 20, 70},
+		{1, 70, 75},
+		{5, 75, 90},
+		{1, 90, 100},
+	}, segmentsMs(path))
+	assert.Equal(t, 100*time.Millisecond, path.Duration())
+
+	contributions := path.Contributions()
+	require.Len(t, contributions, 3)
+	assert.Equal(t, model.SpanID(3), contributions[0].Span.SpanID)
+	assert.Equal(t, 50*time.Millisecond, contributions[0].Duration)
+	assert.InDelta(t, 50.0, contributions[0].Percent, 0.001)
+	assert.Equal(t, model.SpanID(1), contributions[1].Span.SpanID)
+	assert.Equal(t, 35*time.Millisecond, contributions[1].Duration)
+	assert.Equal(t, model.SpanID(5), contributions[2].Span.SpanID)
+}
+
+func TestCriticalPathSequentialChildrenAndNesting(t *testing.T) {
+	trace := &model.Trace{Spans: []*model.Span{
+		pathSpan(1, 0, 0, 100),
+		pathSpan(2, 1, 0, 50),
+		pathSpan(3, 1, 50, 50), // Starts exactly when 2 ends
+		pathSpan(4, 3, 60, 20),
+	}}
+
+	path, err := ComputeCriticalPath(trace)
+	require.NoError(t, err)
+	assert.Equal(t, []segmentMs{
+		{2, 0, 50},
+		{3, 50, 60},
+		{4, 60, 80},
+		{3, 80, 100},
+	}, segmentsMs(path))
+}
+
+func TestCriticalPathClipsChildrenToParent(t *testing.T) {
+	// 2 outlives its parent (e.g. a fire-and-forget call); 3 starts
+	// after the root returned
+	trace := &model.Trace{Spans: []*model.Span{
+		pathSpan(1, 0, 0, 50),
+		pathSpan(2, 1, 30, 100),
+		pathSpan(3, 1, 60, 10),
+	}}
+
+	path, err := ComputeCriticalPath(trace)
+	require.NoError(t, err)
+	assert.Equal(t, []segmentMs{
+		{1, 0, 30},
+		{2, 30, 50},
+	}, segmentsMs(path))
+}
+
+func TestCriticalPathSeveralTopLevelSpans(t *testing.T) {
+	trace := &model.Trace{Spans: []*model.Span{
+		pathSpan(1, 0, 0, 40),
+		pathSpan(2, 99, 50, 30), // Orphan
+		pathSpan(3, 2, 60, 10),
+	}}
+
+	path, err := ComputeCriticalPath(trace)
+	require.NoError(t, err)
+	assert.Equal(t, []segmentMs{
+		{1, 0, 40},
+		{2, 50, 60},
+		{3, 60, 70},
+		{2, 70, 80},
+	}, segmentsMs(path))
+	assert.Equal(t, time.Unix(0, 0), path.Start)
+	assert.Equal(t, 70*time.Millisecond, path.Duration(), "the gap is not on the path")
+}
+
+func TestCriticalPathEmptyTrace(t *testing.T) {
+	_, err := ComputeCriticalPath(&model.Trace{})
+	assert.Error(t, err)
+
+	_, err = ComputeCriticalPath(nil)
+	assert.Error(t, err)
+}
@@ -37,7 +37,8 @@ type OTLPReceiverConfig struct {
 
 // GRPCConfig configures gRPC endpoint
 type GRPCConfig struct {
-	Endpoint string `hcl:"endpoint"`
+	Endpoint        string   `hcl:"endpoint"`
+	IncludeMetadata []string `hcl:"include_metadata,optional"` // Request headers copied into span tags
 }
 
 // HTTPConfig configures HTTP endpoint
@@ -55,26 +56,138 @@ type ProcessorBlock struct {
 
 // ProcessorConfig holds processor-specific configuration
 type ProcessorConfig struct {
-	Batch      *BatchProcessorConfig      `hcl:"batch,block"`
-	Attributes *AttributesProcessorConfig `hcl:"attributes,block"`
+	Batch       *BatchProcessorConfig       `hcl:"batch,block"`
+	Attributes  *AttributesProcessorConfig  `hcl:"attributes,block"`
+	Filter      *FilterProcessorConfig      `hcl:"filter,block"`
+	Redaction   *RedactionProcessorConfig   `hcl:"redaction,block"`
+	SpanMetrics *SpanMetricsProcessorConfig `hcl:"spanmetrics,block"`
+	Dedup       *DedupProcessorConfig       `hcl:"dedup,block"`
+	Limits      *LimitsProcessorConfig      `hcl:"limits,block"`
+	Resource    *ResourceProcessorConfig    `hcl:"resource,block"`
+	K8s         *K8sProcessorConfig         `hcl:"k8sattributes,block"`
+	Rename      *RenameProcessorConfig      `hcl:"rename,block"`
 }
 
 // BatchProcessorConfig configures batch processor
 type BatchProcessorConfig struct {
-	Timeout       string `hcl:"timeout"`
-	SendBatchSize int    `hcl:"send_batch_size"`
+	Timeout           string `hcl:"timeout"`
+	SendBatchSize     int    `hcl:"send_batch_size"`
+	SendBatchMaxBytes int    `hcl:"send_batch_max_bytes,optional"`
+	KeySource         string `hcl:"key_source,optional"` // tag, service or metadata
+	Key               string `hcl:"key,optional"`
+	MaxBatchers       int    `hcl:"max_batchers,optional"`
+	KeepMetadata      bool   `hcl:"keep_metadata,optional"`
 }
 
 // AttributesProcessorConfig configures attributes processor
 type AttributesProcessorConfig struct {
+	Include string            `hcl:"include,optional"`
+	Exclude string            `hcl:"exclude,optional"`
 	Actions []AttributeAction `hcl:"action,block"`
 }
 
 // AttributeAction represents an attribute modification action
 type AttributeAction struct {
-	Key    string `hcl:"key"`
-	Value  string `hcl:"value"`
-	Action string `hcl:"action"`
+	Key           string `hcl:"key,optional"`
+	KeyRegex      string `hcl:"key_regex,optional"`
+	KeyGlob       string `hcl:"key_glob,optional"`
+	Value         string `hcl:"value,optional"`
+	Type          string `hcl:"type,optional"`
+	FromAttribute string `hcl:"from_attribute,optional"`
+	Pattern       string `hcl:"pattern,optional"`
+	ConvertedType string `hcl:"converted_type,optional"`
+	Action        string `hcl:"action"`
+}
+
+// FilterProcessorConfig configures filter processor
+type FilterProcessorConfig struct {
+	MaxTraces int          `hcl:"max_traces,optional"`
+	Rules     []FilterRule `hcl:"rule,block"`
+}
+
+// FilterRule describes spans dropped by the filter processor
+type FilterRule struct {
+	Name           string            `hcl:"name,label"`
+	Service        string            `hcl:"service,optional"`
+	OperationRegex string            `hcl:"operation_regex,optional"`
+	Tags           map[string]string `hcl:"tags,optional"`
+	MinDuration    string            `hcl:"min_duration,optional"`
+	MaxDuration    string            `hcl:"max_duration,optional"`
+	Expression     string            `hcl:"expression,optional"`
+	DropTrace      bool              `hcl:"drop_trace,optional"`
+}
+
+// RedactionProcessorConfig configures redaction processor
+type RedactionProcessorConfig struct {
+	Mode           string   `hcl:"mode,optional"`
+	HashKey        string   `hcl:"hash_key,optional"`
+	AllowedKeys    []string `hcl:"allowed_keys,optional"`
+	IgnoredKeys    []string `hcl:"ignored_keys,optional"`
+	CreditCards    *bool    `hcl:"credit_cards,optional"`
+	Emails         *bool    `hcl:"emails,optional"`
+	JWTs           *bool    `hcl:"jwts,optional"`
+	IPAddresses    *bool    `hcl:"ip_addresses,optional"`
+	CustomPatterns []string `hcl:"custom_patterns,optional"`
+	SummaryTags    *bool    `hcl:"summary_tags,optional"`
+}
+
+// SpanMetricsProcessorConfig configures span metrics (RED) processor
+type SpanMetricsProcessorConfig struct {
+	Buckets       []string `hcl:"buckets,optional"`
+	MaxSeries     int      `hcl:"max_series,optional"`
+	FlushInterval string   `hcl:"flush_interval,optional"`
+	Path          string   `hcl:"path,optional"` // Prometheus endpoint on the health server
+}
+
+// DedupProcessorConfig configures span deduplication processor
+type DedupProcessorConfig struct {
+	TTL         string `hcl:"ttl,optional"`
+	MaxSpans    int    `hcl:"max_spans,optional"`
+	Shards      int    `hcl:"shards,optional"`
+	Merge       bool   `hcl:"merge,optional"`
+	MergeWindow string `hcl:"merge_window,optional"`
+}
+
+// LimitsProcessorConfig configures span and trace size limits
+type LimitsProcessorConfig struct {
+	MaxTags          int `hcl:"max_tags,optional"`
+	MaxLogs          int `hcl:"max_logs,optional"`
+	MaxStringLength  int `hcl:"max_string_length,optional"`
+	MaxBinaryLength  int `hcl:"max_binary_length,optional"`
+	MaxSpansPerTrace int `hcl:"max_spans_per_trace,optional"`
+	MaxTraces        int `hcl:"max_traces,optional"`
+	MaxServices      int `hcl:"max_services,optional"`
+}
+
+// ResourceProcessorConfig configures resource detection
+type ResourceProcessorConfig struct {
+	Detectors           []string `hcl:"detectors,optional"`
+	Policy              string   `hcl:"policy,optional"`
+	RootPath            string   `hcl:"root_path,optional"`
+	OverrideServiceName bool     `hcl:"override_service_name,optional"`
+}
+
+// K8sProcessorConfig configures Kubernetes metadata enrichment
+type K8sProcessorConfig struct {
+	APIServer string   `hcl:"api_server,optional"` // Defaults to the in-cluster API server
+	Namespace string   `hcl:"namespace,optional"`
+	Labels    []string `hcl:"labels,optional"`
+	IPTags    []string `hcl:"ip_tags,optional"`
+}
+
+// RenameProcessorConfig configures operation-name rewriting
+type RenameProcessorConfig struct {
+	Include string       `hcl:"include,optional"`
+	Exclude string       `hcl:"exclude,optional"`
+	Rules   []RenameRule `hcl:"rule,block"`
+}
+
+// RenameRule describes how to rename the operations of a service
+type RenameRule struct {
+	Service        string   `hcl:"service,optional"`
+	Template       string   `hcl:"template,optional"`
+	Extract        []string `hcl:"extract,optional"`
+	NormalizePaths bool     `hcl:"normalize_paths,optional"`
 }
 
 // ExporterBlock represents an exporter configuration block
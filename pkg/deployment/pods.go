@@ -0,0 +1,393 @@
+package deployment
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pod is the pod metadata used to enrich spans
+type Pod struct {
+	Name       string
+	Namespace  string
+	UID        string
+	NodeName   string
+	IP         string
+	Deployment string // Owning Deployment, derived from the ReplicaSet owner
+	Labels     map[string]string
+}
+
+// PodInformer keeps a cache of the cluster's pods indexed by IP, kept up
+// to date with a list+watch against the Kubernetes API server
+type PodInformer struct {
+	apiServer     string
+	namespace     string
+	tokenFile     string
+	token         string
+	client        *http.Client
+	retryInterval time.Duration
+
+	mu     sync.RWMutex
+	pods   map[string]Pod      // namespace/name -> pod
+	byIP   map[string]string   // IP -> namespace/name
+	ipsOf  map[string][]string // namespace/name -> IPs indexed for it
+	synced bool
+}
+
+// InformerConfig configures the pod informer
+type InformerConfig struct {
+	APIServer     string        // e.g. "https://10.0.0.1:443"
+	Namespace     string        // Watch one namespace ("" = all)
+	Token         string        // Bearer token
+	TokenFile     string        // Bearer token file, re-read on every request (takes precedence over Token)
+	CAFile        string        // CA bundle for the API server certificate
+	Client        *http.Client  // Overrides the client built from CAFile
+	RetryInterval time.Duration // Wait before relisting after a failure
+}
+
+// In-cluster service account files
+const (
+	serviceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	serviceAccountCAFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+// InClusterInformerConfig returns the configuration for an informer
+// running in a pod, using its service account
+func InClusterInformerConfig() (InformerConfig, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return InformerConfig{}, fmt.Errorf("not running in a Kubernetes cluster")
+	}
+	return InformerConfig{
+		APIServer:     "https://" + net.JoinHostPort(host, port),
+		TokenFile:     serviceAccountTokenFile,
+		CAFile:        serviceAccountCAFile,
+		RetryInterval: 5 * time.Second,
+	}, nil
+}
+
+// NewPodInformer creates a new pod informer. Call Run to start it.
+func NewPodInformer(config InformerConfig) (*PodInformer, error) {
+	if config.APIServer == "" {
+		return nil, fmt.Errorf("API server address is required")
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = 5 * time.Second
+	}
+
+	client := config.Client
+	if client == nil {
+		client = &http.Client{}
+		if config.CAFile != "" {
+			ca, err := os.ReadFile(config.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA file: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("no certificates in CA file %s", config.CAFile)
+			}
+			client.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+		}
+	}
+
+	return &PodInformer{
+		apiServer:     strings.TrimSuffix(config.APIServer, "/"),
+		namespace:     config.Namespace,
+		tokenFile:     config.TokenFile,
+		token:         config.Token,
+		client:        client,
+		retryInterval: config.RetryInterval,
+		pods:          make(map[string]Pod),
+		byIP:          make(map[string]string),
+		ipsOf:         make(map[string][]string),
+	}, nil
+}
+
+// Run lists and watches pods until ctx is done, relisting after errors
+func (i *PodInformer) Run(ctx context.Context) error {
+	for {
+		resourceVersion, err := i.list(ctx)
+		for err == nil {
+			resourceVersion, err = i.watch(ctx, resourceVersion)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, errWatchExpired) {
+			continue // Relist right away
+		}
+
+		select {
+		case <-time.After(i.retryInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// HasSynced reports whether the initial list has completed
+func (i *PodInformer) HasSynced() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.synced
+}
+
+// PodByIP returns the pod with the given IP
+func (i *PodInformer) PodByIP(ip string) (Pod, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	key, ok := i.byIP[ip]
+	if !ok {
+		return Pod{}, false
+	}
+	pod, ok := i.pods[key]
+	return pod, ok
+}
+
+// errWatchExpired asks for a relist when the resource version is too old
+var errWatchExpired = errors.New("watch expired")
+
+// list replaces the cache with the current pods and returns the list's
+// resource version
+func (i *PodInformer) list(ctx context.Context) (string, error) {
+	resp, err := i.get(ctx, url.Values{})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var list struct {
+		Metadata struct {
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"metadata"`
+		Items []apiPod `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return "", fmt.Errorf("failed to decode pod list: %w", err)
+	}
+
+	pods := make(map[string]Pod, len(list.Items))
+	byIP := make(map[string]string, len(list.Items))
+	ipsOf := make(map[string][]string, len(list.Items))
+	for _, item := range list.Items {
+		pod := item.pod()
+		key := pod.Namespace + "/" + pod.Name
+		pods[key] = pod
+		ips := item.ips()
+		for _, ip := range ips {
+			byIP[ip] = key
+		}
+		ipsOf[key] = ips
+	}
+
+	i.mu.Lock()
+	i.pods, i.byIP, i.ipsOf, i.synced = pods, byIP, ipsOf, true
+	i.mu.Unlock()
+
+	return list.Metadata.ResourceVersion, nil
+}
+
+// watch applies pod events until the server closes the stream and
+// returns the last seen resource version
+func (i *PodInformer) watch(ctx context.Context, resourceVersion string) (string, error) {
+	resp, err := i.get(ctx, url.Values{
+		"watch":               {"1"},
+		"resourceVersion":     {resourceVersion},
+		"allowWatchBookmarks": {"true"},
+	})
+	if err != nil {
+		return resourceVersion, err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event struct {
+			Type   string          `json:"type"`
+			Object json.RawMessage `json:"object"`
+		}
+		if err := decoder.Decode(&event); err != nil {
+			if errors.Is(err, io.EOF) {
+				return resourceVersion, nil // Server timeout; resume watching
+			}
+			return resourceVersion, fmt.Errorf("failed to decode watch event: %w", err)
+		}
+
+		if event.Type == "ERROR" {
+			var status struct {
+				Code int `json:"code"`
+			}
+			json.Unmarshal(event.Object, &status)
+			if status.Code == http.StatusGone {
+				return resourceVersion, errWatchExpired
+			}
+			return resourceVersion, fmt.Errorf("watch error: %s", event.Object)
+		}
+
+		var item apiPod
+		if err := json.Unmarshal(event.Object, &item); err != nil {
+			return resourceVersion, fmt.Errorf("failed to decode pod: %w", err)
+		}
+		resourceVersion = item.Metadata.ResourceVersion
+
+		switch event.Type {
+		case "ADDED", "MODIFIED":
+			i.upsert(item)
+		case "DELETED":
+			i.delete(item)
+		}
+	}
+}
+
+// get requests the pod collection
+func (i *PodInformer) get(ctx context.Context, query url.Values) (*http.Response, error) {
+	path := "/api/v1/pods"
+	if i.namespace != "" {
+		path = "/api/v1/namespaces/" + url.PathEscape(i.namespace) + "/pods"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, i.apiServer+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	token := i.token
+	if i.tokenFile != "" {
+		data, err := os.ReadFile(i.tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token file: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusGone {
+			return nil, errWatchExpired
+		}
+		return nil, fmt.Errorf("unexpected status from API server: %s", resp.Status)
+	}
+	return resp, nil
+}
+
+// upsert adds or replaces a pod
+func (i *PodInformer) upsert(item apiPod) {
+	pod := item.pod()
+	key := pod.Namespace + "/" + pod.Name
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.unindex(key)
+	i.pods[key] = pod
+	ips := item.ips()
+	for _, ip := range ips {
+		i.byIP[ip] = key
+	}
+	i.ipsOf[key] = ips
+}
+
+// delete removes a pod
+func (i *PodInformer) delete(item apiPod) {
+	key := item.Metadata.Namespace + "/" + item.Metadata.Name
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.unindex(key)
+	delete(i.pods, key)
+}
+
+// unindex removes the IPs pointing at a pod; IPs already reused by
+// another pod are left alone
+func (i *PodInformer) unindex(key string) {
+	for _, ip := range i.ipsOf[key] {
+		if i.byIP[ip] == key {
+			delete(i.byIP, ip)
+		}
+	}
+	delete(i.ipsOf, key)
+}
+
+// apiPod is the subset of the Kubernetes Pod object we read
+type apiPod struct {
+	Metadata struct {
+		Name            string            `json:"name"`
+		Namespace       string            `json:"namespace"`
+		UID             string            `json:"uid"`
+		ResourceVersion string            `json:"resourceVersion"`
+		Labels          map[string]string `json:"labels"`
+		OwnerReferences []struct {
+			Kind string `json:"kind"`
+			Name string `json:"name"`
+		} `json:"ownerReferences"`
+	} `json:"metadata"`
+	Spec struct {
+		NodeName    string `json:"nodeName"`
+		HostNetwork bool   `json:"hostNetwork"`
+	} `json:"spec"`
+	Status struct {
+		PodIP  string `json:"podIP"`
+		PodIPs []struct {
+			IP string `json:"ip"`
+		} `json:"podIPs"`
+	} `json:"status"`
+}
+
+// pod converts the API object
+func (p apiPod) pod() Pod {
+	pod := Pod{
+		Name:      p.Metadata.Name,
+		Namespace: p.Metadata.Namespace,
+		UID:       p.Metadata.UID,
+		NodeName:  p.Spec.NodeName,
+		IP:        p.Status.PodIP,
+		Labels:    p.Metadata.Labels,
+	}
+	for _, owner := range p.Metadata.OwnerReferences {
+		if owner.Kind != "ReplicaSet" {
+			continue
+		}
+		// Deployment ReplicaSets are named <deployment>-<pod-template-hash>
+		if hash := p.Metadata.Labels["pod-template-hash"]; hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+			pod.Deployment = strings.TrimSuffix(owner.Name, "-"+hash)
+		}
+	}
+	return pod
+}
+
+// ips returns the addresses a pod is reachable at. Host-network pods
+// share the node's address and are not indexed.
+func (p apiPod) ips() []string {
+	if p.Spec.HostNetwork {
+		return nil
+	}
+	ips := make([]string, 0, len(p.Status.PodIPs)+1)
+	if p.Status.PodIP != "" {
+		ips = append(ips, p.Status.PodIP)
+	}
+	for _, ip := range p.Status.PodIPs {
+		if ip.IP != "" && ip.IP != p.Status.PodIP {
+			ips = append(ips, ip.IP)
+		}
+	}
+	return ips
+}
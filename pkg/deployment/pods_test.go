@@ -0,0 +1,188 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPIServer serves the pod list and streams watch events pushed by
+// the test
+type fakeAPIServer struct {
+	t      *testing.T
+	events chan string
+	mu     sync.Mutex
+	lists  int
+	auth   []string
+}
+
+func (s *fakeAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.auth = append(s.auth, r.Header.Get("Authorization"))
+	s.mu.Unlock()
+
+	assert.Equal(s.t, "/api/v1/namespaces/shop/pods", r.URL.Path)
+
+	if r.URL.Query().Get("watch") == "" {
+		s.mu.Lock()
+		s.lists++
+		s.mu.Unlock()
+		fmt.Fprintf(w, `{"metadata":{"resourceVersion":"10"},"items":[%s]}`,
+			podJSON("checkout-7d9f8-abcde", "10.1.0.5", "7d9f8", "5"))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+	for {
+		select {
+		case event, ok := <-s.events:
+			if !ok {
+				return
+			}
+			fmt.Fprintln(w, event)
+			w.(http.Flusher).Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func podJSON(name, ip, hash, resourceVersion string) string {
+	pod := map[string]any{
+		"metadata": map[string]any{
+			"name":            name,
+			"namespace":       "shop",
+			"uid":             "uid-" + name,
+			"resourceVersion": resourceVersion,
+			"labels":          map[string]string{"app": "checkout", "pod-template-hash": hash},
+			"ownerReferences": []map[string]string{{"kind": "ReplicaSet", "name": "checkout-" + hash}},
+		},
+		"spec":   map[string]any{"nodeName": "node-1"},
+		"status": map[string]any{"podIP": ip, "podIPs": []map[string]string{{"ip": ip}, {"ip": "fd00::5"}}},
+	}
+	data, _ := json.Marshal(pod)
+	return string(data)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPodInformerListAndWatch(t *testing.T) {
+	fake := &fakeAPIServer{t: t, events: make(chan string, 10)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	informer, err := NewPodInformer(InformerConfig{
+		APIServer:     server.URL,
+		Namespace:     "shop",
+		Token:         "secret",
+		RetryInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- informer.Run(ctx) }()
+
+	waitFor(t, informer.HasSynced)
+
+	pod, ok := informer.PodByIP("10.1.0.5")
+	require.True(t, ok)
+	assert.Equal(t, "checkout-7d9f8-abcde", pod.Name)
+	assert.Equal(t, "shop", pod.Namespace)
+	assert.Equal(t, "node-1", pod.NodeName)
+	assert.Equal(t, "checkout", pod.Deployment)
+	assert.Equal(t, "checkout", pod.Labels["app"])
+	_, ok = informer.PodByIP("fd00::5")
+	assert.True(t, ok)
+
+	// A replacement pod is added and the old one deleted
+	fake.events <- `{"type":"ADDED","object":` + podJSON("checkout-7d9f8-fghij", "10.1.0.6", "7d9f8", "11") + `}`
+	fake.events <- `{"type":"DELETED","object":` + podJSON("checkout-7d9f8-abcde", "10.1.0.5", "7d9f8", "12") + `}`
+	waitFor(t, func() bool {
+		_, added := informer.PodByIP("10.1.0.6")
+		_, deleted := informer.PodByIP("10.1.0.5")
+		return added && !deleted
+	})
+
+	// An expired watch triggers a relist
+	fake.events <- `{"type":"ERROR","object":{"kind":"Status","code":410}}`
+	waitFor(t, func() bool {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		return fake.lists == 2
+	})
+	waitFor(t, func() bool {
+		_, ok := informer.PodByIP("10.1.0.5")
+		return ok
+	})
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	for _, auth := range fake.auth {
+		assert.Equal(t, "Bearer secret", auth)
+	}
+}
+
+func TestPodInformerIndexesEvents(t *testing.T) {
+	informer, err := NewPodInformer(InformerConfig{APIServer: "http://unused"})
+	require.NoError(t, err)
+
+	decode := func(data string) apiPod {
+		var pod apiPod
+		require.NoError(t, json.Unmarshal([]byte(data), &pod))
+		return pod
+	}
+	owner := func(ip string) string {
+		pod, ok := informer.PodByIP(ip)
+		if !ok {
+			return ""
+		}
+		return pod.Name
+	}
+
+	informer.upsert(decode(podJSON("a", "10.1.0.5", "1", "1")))
+	assert.Equal(t, "a", owner("10.1.0.5"))
+
+	// a's IP changes: the old one is unindexed
+	informer.upsert(decode(podJSON("a", "10.1.0.7", "1", "2")))
+	assert.Equal(t, "", owner("10.1.0.5"))
+	assert.Equal(t, "a", owner("10.1.0.7"))
+
+	// b reuses a's addresses before a's deletion arrives
+	informer.upsert(decode(podJSON("b", "10.1.0.7", "1", "3")))
+	informer.delete(decode(podJSON("a", "10.1.0.7", "1", "4")))
+	assert.Equal(t, "b", owner("10.1.0.7"))
+	assert.Equal(t, "b", owner("fd00::5"))
+	assert.Equal(t, map[string][]string{"shop/b": {"10.1.0.7", "fd00::5"}}, informer.ipsOf)
+}
+
+func TestApiPodHostNetworkNotIndexed(t *testing.T) {
+	var pod apiPod
+	require.NoError(t, json.Unmarshal([]byte(`{"spec":{"hostNetwork":true},"status":{"podIP":"10.0.0.1"}}`), &pod))
+	assert.Empty(t, pod.ips())
+}
+
+func TestNewPodInformerRequiresAPIServer(t *testing.T) {
+	_, err := NewPodInformer(InformerConfig{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,351 @@
+// Package filter implements a small predicate language over model.Span.
+//
+// Expressions are compiled once into a tree of closures and evaluated
+// without allocation in the hot path:
+//
+//	service == "checkout" && duration > 500ms && tags["http.method"] == "POST"
+//
+// Fields: service, operation (alias name), status (unset, ok, error),
+// kind, trace_id, duration, tags["key"] and process["key"].
+// Operators: == != < <= > >= =~ !~ && || ! and parentheses.
+// A bare tags["key"] or process["key"] tests for presence.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+)
+
+// predicate is a compiled expression node
+type predicate func(*model.Span) bool
+
+// Expression is a compiled span predicate
+type Expression struct {
+	src  string
+	eval predicate
+}
+
+// Compile parses an expression
+func Compile(src string) (*Expression, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, fmt.Errorf("filter %q: %w", src, err)
+	}
+
+	p := &parser{tokens: tokens}
+	eval, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("filter %q: %w", src, err)
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("filter %q: unexpected %q at offset %d", src, tok.text, tok.pos)
+	}
+
+	return &Expression{src: src, eval: eval}, nil
+}
+
+// MustCompile is like Compile but panics on error
+func MustCompile(src string) *Expression {
+	expr, err := Compile(src)
+	if err != nil {
+		panic(err)
+	}
+	return expr
+}
+
+// Match evaluates the expression against a span
+func (e *Expression) Match(span *model.Span) bool {
+	return e.eval(span)
+}
+
+// String returns the source expression
+func (e *Expression) String() string {
+	return e.src
+}
+
+// parser is a recursive-descent parser over tokens
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		return tok, fmt.Errorf("expected %s at offset %d", what, tok.pos)
+	}
+	return tok, nil
+}
+
+// parseOr handles a || b
+func (p *parser) parseOr() (predicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(s *model.Span) bool { return l(s) || right(s) }
+	}
+	return left, nil
+}
+
+// parseAnd handles a && b
+func (p *parser) parseAnd() (predicate, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().text == "&&" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(s *model.Span) bool { return l(s) && right(s) }
+	}
+	return left, nil
+}
+
+// parseUnary handles !a
+func (p *parser) parseUnary() (predicate, error) {
+	if tok := p.peek(); tok.kind == tokOp && tok.text == "!" {
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(s *model.Span) bool { return !inner(s) }, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary handles parentheses, literals and comparisons
+func (p *parser) parsePrimary() (predicate, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return func(*model.Span) bool { return true }, nil
+		case "false":
+			return func(*model.Span) bool { return false }, nil
+		case "duration":
+			return p.parseDurationComparison()
+		case "tags", "process":
+			return p.parseTagComparison(tok.text)
+		}
+		if field, ok := stringFields[tok.text]; ok {
+			return p.parseStringComparison(field)
+		}
+		return nil, fmt.Errorf("unknown field %q at offset %d", tok.text, tok.pos)
+	}
+
+	return nil, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+}
+
+// parseOperator reads a comparison operator
+func (p *parser) parseOperator() (token, error) {
+	tok := p.next()
+	switch tok.text {
+	case "==", "!=", "<", "<=", ">", ">=", "=~", "!~":
+		if tok.kind == tokOp {
+			return tok, nil
+		}
+	}
+	return tok, fmt.Errorf("expected comparison operator at offset %d", tok.pos)
+}
+
+// parseStringComparison compiles <field> <op> "literal"
+func (p *parser) parseStringComparison(field func(*model.Span) string) (predicate, error) {
+	op, err := p.parseOperator()
+	if err != nil {
+		return nil, err
+	}
+	lit, err := p.expect(tokString, "string literal")
+	if err != nil {
+		return nil, err
+	}
+	cmp, err := compareStrings(op, lit.text)
+	if err != nil {
+		return nil, err
+	}
+	return func(s *model.Span) bool { return cmp(field(s)) }, nil
+}
+
+// parseDurationComparison compiles duration <op> 500ms
+func (p *parser) parseDurationComparison() (predicate, error) {
+	op, err := p.parseOperator()
+	if err != nil {
+		return nil, err
+	}
+	lit, err := p.expect(tokDuration, "duration literal (e.g. 500ms)")
+	if err != nil {
+		return nil, err
+	}
+	d, err := time.ParseDuration(lit.text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid duration %q at offset %d", lit.text, lit.pos)
+	}
+	cmp, err := compareOrdered(op, d)
+	if err != nil {
+		return nil, err
+	}
+	return func(s *model.Span) bool { return cmp(s.Duration) }, nil
+}
+
+// parseTagComparison compiles tags["key"] [<op> literal]
+func (p *parser) parseTagComparison(scope string) (predicate, error) {
+	if _, err := p.expect(tokLBracket, "'['"); err != nil {
+		return nil, err
+	}
+	key, err := p.expect(tokString, "tag key")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokRBracket, "']'"); err != nil {
+		return nil, err
+	}
+
+	lookup := spanTag(key.text)
+	if scope == "process" {
+		lookup = processTag(key.text)
+	}
+
+	// Bare tags["key"] tests for presence
+	if p.peek().kind != tokOp || p.peek().text == "&&" || p.peek().text == "||" {
+		return func(s *model.Span) bool {
+			_, ok := lookup(s)
+			return ok
+		}, nil
+	}
+
+	op, err := p.parseOperator()
+	if err != nil {
+		return nil, err
+	}
+	lit := p.next()
+
+	// A missing tag satisfies only !=
+	missing := op.text == "!=" || op.text == "!~"
+
+	switch {
+	case lit.kind == tokString:
+		cmp, err := compareStrings(op, lit.text)
+		if err != nil {
+			return nil, err
+		}
+		return func(s *model.Span) bool {
+			tag, ok := lookup(s)
+			if !ok {
+				return missing
+			}
+			return cmp(tag.AsString())
+		}, nil
+
+	case lit.kind == tokNumber:
+		n, err := strconv.ParseFloat(lit.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", lit.text, lit.pos)
+		}
+		cmp, err := compareOrdered(op, n)
+		if err != nil {
+			return nil, err
+		}
+		return func(s *model.Span) bool {
+			tag, ok := lookup(s)
+			if !ok {
+				return missing
+			}
+			v, ok := tag.AsFloat64()
+			if !ok {
+				return missing
+			}
+			return cmp(v)
+		}, nil
+
+	case lit.kind == tokIdent && (lit.text == "true" || lit.text == "false"):
+		want := lit.text == "true"
+		if op.text != "==" && op.text != "!=" {
+			return nil, fmt.Errorf("operator %s not supported for booleans", op.text)
+		}
+		equal := op.text == "=="
+		return func(s *model.Span) bool {
+			tag, ok := lookup(s)
+			if !ok {
+				return missing
+			}
+			return (tag.VType == model.BoolType && tag.VBool == want) == equal
+		}, nil
+	}
+
+	return nil, fmt.Errorf("expected literal at offset %d", lit.pos)
+}
+
+// compareStrings builds a string comparison, compiling regexes once
+func compareStrings(op token, lit string) (func(string) bool, error) {
+	switch op.text {
+	case "=~", "!~":
+		re, err := regexp.Compile(lit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex at offset %d: %w", op.pos, err)
+		}
+		if op.text == "=~" {
+			return re.MatchString, nil
+		}
+		return func(v string) bool { return !re.MatchString(v) }, nil
+	}
+	return compareOrdered(op, lit)
+}
+
+// compareOrdered builds an ordered comparison against a literal
+func compareOrdered[T string | float64 | time.Duration](op token, lit T) (func(T) bool, error) {
+	switch op.text {
+	case "==":
+		return func(v T) bool { return v == lit }, nil
+	case "!=":
+		return func(v T) bool { return v != lit }, nil
+	case "<":
+		return func(v T) bool { return v < lit }, nil
+	case "<=":
+		return func(v T) bool { return v <= lit }, nil
+	case ">":
+		return func(v T) bool { return v > lit }, nil
+	case ">=":
+		return func(v T) bool { return v >= lit }, nil
+	}
+	return nil, fmt.Errorf("operator %s not supported at offset %d", op.text, op.pos)
+}
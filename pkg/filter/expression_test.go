@@ -0,0 +1,133 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+)
+
+func testSpan() *model.Span {
+	return &model.Span{
+		TraceID:       model.TraceID{Low: 42},
+		OperationName: "POST /cart",
+		Duration:      750 * time.Millisecond,
+		Tags: []model.KeyValue{
+			{Key: "http.method", VType: model.StringType, VStr: "POST"},
+			{Key: "http.status_code", VType: model.Int64Type, VInt64: 201},
+			{Key: "span.kind", VType: model.StringType, VStr: "server"},
+			{Key: "cache.hit", VType: model.BoolType, VBool: false},
+		},
+		Process: &model.Process{
+			ServiceName: "checkout",
+			Tags:        []model.KeyValue{{Key: "region", VType: model.StringType, VStr: "eu-west-1"}},
+		},
+	}
+}
+
+func TestExpressionMatch(t *testing.T) {
+	tests := []struct {
+		expr  string
+		match bool
+	}{
+		{`service == "checkout" && duration > 500ms && tags["http.method"] == "POST"`, true},
+		{`service == "checkout" && duration > 1s`, false},
+		{`service != "checkout" || operation =~ "^POST "`, true},
+		{`!(service == "checkout")`, false},
+		{`name !~ "health"`, true},
+		{`duration >= 750ms && duration <= 0.75s`, true},
+		{`tags["http.status_code"] >= 200 && tags["http.status_code"] < 300`, true},
+		{`tags["http.status_code"] == "201"`, true},
+		{`tags["cache.hit"] == false`, true},
+		{`tags["cache.hit"] == true`, false},
+		{`tags["http.route"]`, false},
+		{`tags["http.method"] && kind == "server"`, true},
+		{`tags["missing"] != "x"`, true},
+		{`tags["missing"] == "x"`, false},
+		{`process["region"] =~ "^eu-"`, true},
+		{`status == "unset"`, true},
+		{`trace_id =~ "2a$"`, true},
+		{`false || (true && !false)`, true},
+	}
+
+	span := testSpan()
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := Compile(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.match, expr.Match(span))
+		})
+	}
+}
+
+func TestExpressionStatus(t *testing.T) {
+	expr := MustCompile(`status == "error"`)
+
+	span := testSpan()
+	assert.False(t, expr.Match(span))
+
+	span.Tags = append(span.Tags, model.KeyValue{Key: "otel.status_code", VType: model.StringType, VStr: "ERROR"})
+	assert.True(t, expr.Match(span))
+
+	span = testSpan()
+	span.Tags[1].VInt64 = 503
+	assert.True(t, expr.Match(span))
+}
+
+func TestExpressionCompileErrors(t *testing.T) {
+	tests := []string{
+		``,
+		`service`,
+		`service ==`,
+		`service == 3`,
+		`duration > 500`,
+		`duration =~ "x"`,
+		`tags["a"] > true`,
+		`tags[a] == "x"`,
+		`unknown == "x"`,
+		`service == "x" &&`,
+		`(service == "x"`,
+		`service == "x")`,
+		`operation =~ "("`,
+		`service == "unterminated`,
+		`service @ "x"`,
+	}
+
+	for _, src := range tests {
+		t.Run(src, func(t *testing.T) {
+			_, err := Compile(src)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	span := testSpan()
+
+	var nilMatcher *Matcher
+	assert.True(t, nilMatcher.Match(span))
+
+	m, err := NewMatcher(`service == "checkout"`, `operation =~ "/cart$"`)
+	require.NoError(t, err)
+	assert.False(t, m.Match(span))
+
+	m, err = NewMatcher(`service == "checkout"`, `kind == "client"`)
+	require.NoError(t, err)
+	assert.True(t, m.Match(span))
+
+	_, err = NewMatcher(`service ==`, "")
+	assert.Error(t, err)
+}
+
+func BenchmarkExpressionMatch(b *testing.B) {
+	expr := MustCompile(`service == "checkout" && duration > 500ms && tags["http.method"] == "POST"`)
+	span := testSpan()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		expr.Match(span)
+	}
+}
@@ -0,0 +1,64 @@
+package filter
+
+import (
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+)
+
+// Span status values exposed to expressions
+const (
+	StatusUnset = "unset"
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// stringFields are span fields compared as strings
+var stringFields = map[string]func(*model.Span) string{
+	"service":   serviceOf,
+	"operation": func(s *model.Span) string { return s.OperationName },
+	"name":      func(s *model.Span) string { return s.OperationName },
+	"status":    statusOf,
+	"kind":      kindOf,
+	"trace_id":  func(s *model.Span) string { return s.TraceID.String() },
+}
+
+// serviceOf returns the service name of the span's process
+func serviceOf(span *model.Span) string {
+	if span.Process == nil {
+		return ""
+	}
+	return span.Process.ServiceName
+}
+
+// statusOf returns the span status (see model.Span.EffectiveStatus)
+func statusOf(span *model.Span) string {
+	switch span.EffectiveStatus().Code {
+	case model.StatusError:
+		return StatusError
+	case model.StatusOK:
+		return StatusOK
+	default:
+		return StatusUnset
+	}
+}
+
+// kindOf returns the span kind (see model.Span.EffectiveKind)
+func kindOf(span *model.Span) string {
+	return string(span.EffectiveKind())
+}
+
+// spanTag looks up a span tag
+func spanTag(key string) func(*model.Span) (model.KeyValue, bool) {
+	return func(span *model.Span) (model.KeyValue, bool) {
+		return span.Tag(key)
+	}
+}
+
+// processTag looks up a process tag
+func processTag(key string) func(*model.Span) (model.KeyValue, bool) {
+	return func(span *model.Span) (model.KeyValue, bool) {
+		if span.Process == nil {
+			return model.KeyValue{}, false
+		}
+		return span.Process.Tag(key)
+	}
+}
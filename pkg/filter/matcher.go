@@ -0,0 +1,49 @@
+package filter
+
+import (
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+)
+
+// Matcher combines include and exclude expressions.
+// Processors use it to decide which spans they apply to.
+type Matcher struct {
+	include *Expression
+	exclude *Expression
+}
+
+// NewMatcher compiles include/exclude expressions; either may be empty
+func NewMatcher(include, exclude string) (*Matcher, error) {
+	m := &Matcher{}
+
+	if include != "" {
+		expr, err := Compile(include)
+		if err != nil {
+			return nil, err
+		}
+		m.include = expr
+	}
+	if exclude != "" {
+		expr, err := Compile(exclude)
+		if err != nil {
+			return nil, err
+		}
+		m.exclude = expr
+	}
+
+	return m, nil
+}
+
+// Match reports whether the span is included and not excluded.
+// A nil Matcher matches every span.
+func (m *Matcher) Match(span *model.Span) bool {
+	if m == nil {
+		return true
+	}
+	if m.include != nil && !m.include.Match(span) {
+		return false
+	}
+	if m.exclude != nil && m.exclude.Match(span) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package model
+
+// Batch is a group of spans sharing one process, the unit exporters send
+// in a single request
+type Batch struct {
+	Process *Process `json:"process"`
+	Spans   []*Span  `json:"spans"`
+}
+
+// GroupByProcess splits spans into batches of equal processes (see
+// Process.Equal), in order of first appearance. Spans without a process
+// form their own batch with a nil Process.
+func GroupByProcess(spans []*Span) []Batch {
+	batches := make([]Batch, 0, 1)
+	index := make(map[string]int)
+	// Spans usually share their process; its key is computed once
+	keys := make(map[*Process]string)
+	for _, span := range spans {
+		key := ""
+		if span.Process != nil {
+			var ok bool
+			if key, ok = keys[span.Process]; !ok {
+				// Non-empty, unlike the key of spans without a process
+				key = "p" + processKey(span.Process)
+				keys[span.Process] = key
+			}
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(batches)
+			index[key] = i
+			batches = append(batches, Batch{Process: span.Process})
+		}
+		batches[i].Spans = append(batches[i].Spans, span)
+	}
+	return batches
+}
+
+// Size estimates the encoded size of a span in bytes, for batch limits.
+// It counts the variable-length content plus a fixed overhead per field.
+func (s *Span) Size() int {
+	size := 64 + len(s.OperationName) + len(s.ProcessID)
+	size += 24 * len(s.References)
+	size += tagsSize(s.Tags)
+	for _, log := range s.Logs {
+		size += 16 + tagsSize(log.Fields)
+	}
+	if s.Process != nil {
+		size += len(s.Process.ServiceName) + tagsSize(s.Process.Tags)
+	}
+	for _, warning := range s.Warnings {
+		size += 2 + len(warning)
+	}
+	return size
+}
+
+// tagsSize estimates the encoded size of tags
+func tagsSize(tags []KeyValue) int {
+	size := 0
+	for _, tag := range tags {
+		size += 16 + len(tag.Key) + len(tag.VStr) + len(tag.VBinary)
+	}
+	return size
+}
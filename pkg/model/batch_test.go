@@ -0,0 +1,38 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupByProcess(t *testing.T) {
+	frontend := &Process{ServiceName: "frontend", Tags: []KeyValue{{Key: "ip", VType: StringType, VStr: "10.0.0.1"}}}
+	frontendCopy := &Process{ServiceName: "frontend", Tags: []KeyValue{{Key: "ip", VType: StringType, VStr: "10.0.0.1"}}}
+	otherHost := &Process{ServiceName: "frontend", Tags: []KeyValue{{Key: "ip", VType: StringType, VStr: "10.0.0.2"}}}
+
+	spans := []*Span{
+		{SpanID: 1, Process: frontend},
+		{SpanID: 2, Process: otherHost},
+		{SpanID: 3, Process: frontendCopy},
+		{SpanID: 4},
+	}
+
+	batches := GroupByProcess(spans)
+	require.Len(t, batches, 3)
+	assert.Same(t, frontend, batches[0].Process)
+	assert.Equal(t, []*Span{spans[0], spans[2]}, batches[0].Spans)
+	assert.Same(t, otherHost, batches[1].Process)
+	assert.Nil(t, batches[2].Process)
+	assert.Equal(t, []*Span{spans[3]}, batches[2].Spans)
+}
+
+func TestSpanSizeGrowsWithContent(t *testing.T) {
+	small := &Span{OperationName: "op"}
+	large := &Span{
+		OperationName: "op",
+		Tags:          []KeyValue{{Key: "payload", VType: StringType, VStr: string(make([]byte, 1000))}},
+	}
+	assert.Greater(t, large.Size(), small.Size()+1000)
+}
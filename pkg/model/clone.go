@@ -0,0 +1,112 @@
+package model
+
+// Clone returns a deep copy of the span that shares no memory with it,
+// so either can be modified without affecting the other. The copy's
+// Process is a copy too.
+func (s *Span) Clone() *Span {
+	if s == nil {
+		return nil
+	}
+	return s.cloneWithProcess(s.Process.Clone())
+}
+
+// cloneWithProcess copies the span with the given Process
+func (s *Span) cloneWithProcess(process *Process) *Span {
+	clone := *s
+	if s.Status != nil {
+		status := *s.Status
+		clone.Status = &status
+	}
+	clone.References = cloneSlice(s.References)
+	clone.Tags = cloneKeyValues(s.Tags)
+	if s.Logs != nil {
+		clone.Logs = make([]Log, len(s.Logs))
+		for i, log := range s.Logs {
+			clone.Logs[i] = Log{Timestamp: log.Timestamp, Fields: cloneKeyValues(log.Fields)}
+		}
+	}
+	clone.Process = process
+	clone.Warnings = cloneSlice(s.Warnings)
+	return &clone
+}
+
+// Clone returns a deep copy of the process
+func (p *Process) Clone() *Process {
+	if p == nil {
+		return nil
+	}
+	return &Process{ServiceName: p.ServiceName, Tags: cloneKeyValues(p.Tags)}
+}
+
+// Clone returns a deep copy of the trace. Spans that shared a process
+// share its copy.
+func (t *Trace) Clone() *Trace {
+	if t == nil {
+		return nil
+	}
+	clone := &Trace{TraceID: t.TraceID, Warnings: cloneSlice(t.Warnings)}
+
+	processes := processCloner{}
+	if t.Processes != nil {
+		clone.Processes = make([]*Process, len(t.Processes))
+		for i, p := range t.Processes {
+			clone.Processes[i] = processes.clone(p)
+		}
+	}
+	clone.Spans = processes.cloneSpans(t.Spans)
+	return clone
+}
+
+// CloneSpans returns a deep copy of a batch of spans (see Span.Clone).
+// Spans that shared a process share its copy.
+func CloneSpans(spans []*Span) []*Span {
+	processes := processCloner{}
+	return processes.cloneSpans(spans)
+}
+
+// processCloner copies each process once
+type processCloner map[*Process]*Process
+
+func (c processCloner) clone(p *Process) *Process {
+	if p == nil {
+		return nil
+	}
+	if copied, ok := c[p]; ok {
+		return copied
+	}
+	copied := p.Clone()
+	c[p] = copied
+	return copied
+}
+
+func (c processCloner) cloneSpans(spans []*Span) []*Span {
+	if spans == nil {
+		return nil
+	}
+	clone := make([]*Span, len(spans))
+	for i, span := range spans {
+		if span != nil {
+			clone[i] = span.cloneWithProcess(c.clone(span.Process))
+		}
+	}
+	return clone
+}
+
+// cloneKeyValues copies tags including binary values
+func cloneKeyValues(kvs []KeyValue) []KeyValue {
+	clone := cloneSlice(kvs)
+	for i := range clone {
+		if clone[i].VBinary != nil {
+			clone[i].VBinary = cloneSlice(clone[i].VBinary)
+		}
+	}
+	return clone
+}
+
+// cloneSlice copies a slice, keeping nil and empty slices apart
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
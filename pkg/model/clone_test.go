@@ -0,0 +1,65 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpanClone(t *testing.T) {
+	span := jsonTestTrace().Spans[0]
+	span.References = []Reference{{RefType: ChildOf, TraceID: span.TraceID, SpanID: 9}}
+
+	clone := span.Clone()
+	require.Equal(t, span, clone)
+
+	// Modifying the clone leaves the original alone
+	clone.Tags[0].VStr = "POST"
+	clone.Tags[4].VBinary[0] = 0xaa
+	clone.Tags = append(clone.Tags, String("extra", "x"))
+	clone.References[0].SpanID = 10
+	clone.Logs[0].Fields[0].VStr = "give up"
+	clone.Process.Tags[0].VStr = "web-2"
+	clone.Warnings[0] = "changed"
+	clone.Status.Message = "changed"
+
+	original := jsonTestTrace().Spans[0]
+	original.References = []Reference{{RefType: ChildOf, TraceID: span.TraceID, SpanID: 9}}
+	assert.Equal(t, original, span)
+}
+
+func TestSpanCloneNil(t *testing.T) {
+	var span *Span
+	assert.Nil(t, span.Clone())
+
+	clone := (&Span{SpanID: 1}).Clone()
+	assert.Nil(t, clone.Tags)
+	assert.Nil(t, clone.Process)
+}
+
+func TestTraceClone(t *testing.T) {
+	trace := jsonTestTrace()
+	trace.AddSpan(&Span{TraceID: trace.TraceID, SpanID: 3, Process: &Process{ServiceName: "cart"}})
+
+	clone := trace.Clone()
+	require.Equal(t, trace, clone)
+	assert.NotSame(t, trace.Processes[1], clone.Processes[1])
+	// Spans of one process keep sharing it
+	assert.Same(t, clone.Processes[1], clone.Spans[1].Process)
+	assert.Same(t, clone.Spans[1].Process, clone.Spans[2].Process)
+
+	clone.Processes[1].ServiceName = "basket"
+	assert.Equal(t, "cart", trace.Spans[2].Process.ServiceName)
+}
+
+func TestCloneSpans(t *testing.T) {
+	process := &Process{ServiceName: "cart"}
+	spans := []*Span{{SpanID: 1, Process: process}, nil, {SpanID: 2, Process: process}, {SpanID: 3}}
+
+	clone := CloneSpans(spans)
+	require.Equal(t, spans, clone)
+	assert.NotSame(t, process, clone[0].Process)
+	assert.Same(t, clone[0].Process, clone[2].Process, "spans of one process keep sharing it")
+	assert.Nil(t, CloneSpans(nil))
+}
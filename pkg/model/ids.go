@@ -0,0 +1,103 @@
+package model
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// ParseTraceID parses a hex trace ID of up to 32 characters. Shorter
+// forms, such as 64-bit IDs written as 16 characters, fill the low half.
+func ParseTraceID(s string) (TraceID, error) {
+	if s == "" || len(s) > 32 {
+		return TraceID{}, fmt.Errorf("invalid trace ID %q: length must be 1 to 32 hex characters", s)
+	}
+	var id TraceID
+	if len(s) > 16 {
+		high, err := strconv.ParseUint(s[:len(s)-16], 16, 64)
+		if err != nil {
+			return TraceID{}, fmt.Errorf("invalid trace ID %q: %w", s, err)
+		}
+		id.High = high
+		s = s[len(s)-16:]
+	}
+	low, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return TraceID{}, fmt.Errorf("invalid trace ID %q: %w", s, err)
+	}
+	id.Low = low
+	return id, nil
+}
+
+// ParseSpanID parses a hex span ID of up to 16 characters
+func ParseSpanID(s string) (SpanID, error) {
+	if s == "" || len(s) > 16 {
+		return 0, fmt.Errorf("invalid span ID %q: length must be 1 to 16 hex characters", s)
+	}
+	id, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid span ID %q: %w", s, err)
+	}
+	return SpanID(id), nil
+}
+
+// TraceIDFromBytes converts a 16-byte big-endian trace ID; 8 bytes fill
+// the low half
+func TraceIDFromBytes(b []byte) (TraceID, error) {
+	switch len(b) {
+	case 16:
+		return TraceID{High: binary.BigEndian.Uint64(b[:8]), Low: binary.BigEndian.Uint64(b[8:])}, nil
+	case 8:
+		return TraceID{Low: binary.BigEndian.Uint64(b)}, nil
+	default:
+		return TraceID{}, fmt.Errorf("invalid trace ID: %d bytes, want 16 or 8", len(b))
+	}
+}
+
+// Bytes returns the 16-byte big-endian form of the trace ID
+func (t TraceID) Bytes() []byte {
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b[:8], t.High)
+	binary.BigEndian.PutUint64(b[8:], t.Low)
+	return b
+}
+
+// SpanIDFromBytes converts an 8-byte big-endian span ID
+func SpanIDFromBytes(b []byte) (SpanID, error) {
+	if len(b) != 8 {
+		return 0, fmt.Errorf("invalid span ID: %d bytes, want 8", len(b))
+	}
+	return SpanID(binary.BigEndian.Uint64(b)), nil
+}
+
+// Bytes returns the 8-byte big-endian form of the span ID
+func (s SpanID) Bytes() []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(s))
+	return b
+}
+
+// UnmarshalJSON accepts the hex string written by MarshalJSON, and the
+// {"high": ..., "low": ...} object form
+func (t *TraceID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		id, err := ParseTraceID(s)
+		if err != nil {
+			return err
+		}
+		*t = id
+		return nil
+	}
+
+	var parts struct {
+		High uint64 `json:"high"`
+		Low  uint64 `json:"low"`
+	}
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return fmt.Errorf("invalid trace ID %s", data)
+	}
+	*t = TraceID{High: parts.High, Low: parts.Low}
+	return nil
+}
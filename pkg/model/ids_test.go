@@ -0,0 +1,102 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceIDStringIsPadded(t *testing.T) {
+	assert.Equal(t, "0000000000000000000000000000002a", TraceID{Low: 42}.String())
+	assert.Equal(t, "0000000000000001000000000000002a", TraceID{High: 1, Low: 42}.String())
+}
+
+func TestParseTraceID(t *testing.T) {
+	tests := []struct {
+		input string
+		want  TraceID
+	}{
+		{"2a", TraceID{Low: 42}},
+		{"000000000000002a", TraceID{Low: 42}},
+		{"0000000000000001000000000000002a", TraceID{High: 1, Low: 42}},
+		{"1000000000000002a", TraceID{High: 1, Low: 42}},
+		{"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF", TraceID{High: ^uint64(0), Low: ^uint64(0)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			id, err := ParseTraceID(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, id)
+		})
+	}
+
+	for _, input := range []string{"", "xyz", "000000000000000000000000000000000", "0x2a"} {
+		_, err := ParseTraceID(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParseSpanID(t *testing.T) {
+	id, err := ParseSpanID("000000000000002a")
+	require.NoError(t, err)
+	assert.Equal(t, SpanID(42), id)
+
+	for _, input := range []string{"", "g", "00000000000000000"} {
+		_, err := ParseSpanID(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestIDStringRoundTrip(t *testing.T) {
+	for _, id := range []TraceID{{Low: 1}, {High: 1}, {High: 0xdeadbeef, Low: 0xcafe}} {
+		parsed, err := ParseTraceID(id.String())
+		require.NoError(t, err)
+		assert.Equal(t, id, parsed)
+	}
+
+	parsed, err := ParseSpanID(SpanID(0xabcdef).String())
+	require.NoError(t, err)
+	assert.Equal(t, SpanID(0xabcdef), parsed)
+}
+
+func TestIDBytesRoundTrip(t *testing.T) {
+	traceID := TraceID{High: 0x0102030405060708, Low: 0x090a0b0c0d0e0f10}
+	b := traceID.Bytes()
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, b)
+	parsed, err := TraceIDFromBytes(b)
+	require.NoError(t, err)
+	assert.Equal(t, traceID, parsed)
+
+	parsed, err = TraceIDFromBytes(b[8:])
+	require.NoError(t, err)
+	assert.Equal(t, TraceID{Low: traceID.Low}, parsed)
+
+	spanID := SpanID(0x1122334455667788)
+	parsedSpan, err := SpanIDFromBytes(spanID.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, spanID, parsedSpan)
+
+	_, err = TraceIDFromBytes(b[:3])
+	assert.Error(t, err)
+	_, err = SpanIDFromBytes(b)
+	assert.Error(t, err)
+}
+
+func TestTraceIDJSONRoundTrip(t *testing.T) {
+	id := TraceID{High: 7, Low: 42}
+	data, err := json.Marshal(id)
+	require.NoError(t, err)
+	assert.Equal(t, `"0000000000000007000000000000002a"`, string(data))
+
+	var decoded TraceID
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, id, decoded)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"high":7,"low":42}`), &decoded))
+	assert.Equal(t, id, decoded)
+
+	assert.Error(t, json.Unmarshal([]byte(`"not-hex"`), &decoded))
+	assert.Error(t, json.Unmarshal([]byte(`42`), &decoded))
+}
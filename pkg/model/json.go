@@ -0,0 +1,509 @@
+package model
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// JSONFormat selects the JSON representation of traces
+type JSONFormat string
+
+const (
+	// JSONNative is the encoding/json form of Trace: a JSON array of
+	// traces with RFC 3339 timestamps and nanosecond durations
+	JSONNative JSONFormat = "native"
+	// JSONJaegerUI is the /api/traces response of jaeger-query, as read
+	// by the Jaeger UI: {"data": [...]} with microsecond timestamps and
+	// durations and a processes map keyed by ProcessID
+	JSONJaegerUI JSONFormat = "jaeger-ui"
+)
+
+// MarshalJSON writes the span ID as 16 hex characters
+func (s SpanID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON accepts a hex string, and the number form written by
+// earlier versions
+func (s *SpanID) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		id, err := ParseSpanID(str)
+		if err != nil {
+			return err
+		}
+		*s = id
+		return nil
+	}
+
+	var n uint64
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid span ID %s", data)
+	}
+	*s = SpanID(n)
+	return nil
+}
+
+// MarshalTraces encodes traces in the given format
+func MarshalTraces(traces []*Trace, format JSONFormat) ([]byte, error) {
+	switch format {
+	case JSONNative, "":
+		return json.Marshal(traces)
+	case JSONJaegerUI:
+		response := uiResponse{Data: make([]uiTrace, len(traces))}
+		for i, trace := range traces {
+			response.Data[i] = toUITrace(trace)
+		}
+		return json.Marshal(response)
+	default:
+		return nil, fmt.Errorf("unknown JSON format: %s", format)
+	}
+}
+
+// UnmarshalTraces decodes traces written in the given format. In strict
+// mode unknown fields and trailing data are rejected, and so are traces
+// with missing IDs, spans of another trace, dangling process IDs and
+// unknown value types; otherwise these are decoded as far as possible.
+func UnmarshalTraces(data []byte, format JSONFormat, strict bool) ([]*Trace, error) {
+	switch format {
+	case JSONNative, "":
+		var traces []*Trace
+		if err := decodeJSON(data, &traces, strict); err != nil {
+			return nil, err
+		}
+		for _, trace := range traces {
+			if strict {
+				if err := checkTrace(trace); err != nil {
+					return nil, err
+				}
+			}
+			if trace != nil {
+				linkProcesses(trace)
+			}
+		}
+		return traces, nil
+
+	case JSONJaegerUI:
+		var response uiResponse
+		if err := decodeJSON(data, &response, strict); err != nil {
+			return nil, err
+		}
+		if strict && len(response.Errors) > 0 {
+			return nil, fmt.Errorf("response has errors: %s", response.Errors[0].Msg)
+		}
+		traces := make([]*Trace, len(response.Data))
+		for i, ui := range response.Data {
+			trace, err := fromUITrace(ui, strict)
+			if err != nil {
+				return nil, err
+			}
+			traces[i] = trace
+		}
+		return traces, nil
+
+	default:
+		return nil, fmt.Errorf("unknown JSON format: %s", format)
+	}
+}
+
+// linkProcesses points each span's Process at the trace process its
+// ProcessID references, so that decoded spans share processes again
+func linkProcesses(trace *Trace) {
+	for _, span := range trace.Spans {
+		if span == nil || span.ProcessID == "" {
+			continue
+		}
+		if process := trace.ProcessByID(span.ProcessID); process != nil {
+			span.Process = process
+		}
+	}
+}
+
+// decodeJSON unmarshals data, rejecting unknown fields and trailing data
+// in strict mode
+func decodeJSON(data []byte, v interface{}, strict bool) error {
+	if !strict {
+		return json.Unmarshal(data, v)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return fmt.Errorf("unexpected data after JSON value")
+	}
+	return nil
+}
+
+// checkTrace validates a decoded native trace in strict mode
+func checkTrace(trace *Trace) error {
+	if trace == nil {
+		return fmt.Errorf("null trace")
+	}
+	if !trace.TraceID.IsValid() {
+		return fmt.Errorf("trace without trace ID")
+	}
+	for i, span := range trace.Spans {
+		if span == nil {
+			return fmt.Errorf("trace %s: null span at index %d", trace.TraceID, i)
+		}
+		if span.TraceID != trace.TraceID {
+			return fmt.Errorf("trace %s: span %s belongs to trace %s", trace.TraceID, span.SpanID, span.TraceID)
+		}
+		if !span.SpanID.IsValid() {
+			return fmt.Errorf("trace %s: span without span ID at index %d", trace.TraceID, i)
+		}
+		if !span.Kind.IsValid() {
+			return fmt.Errorf("span %s: unknown kind %q", span.SpanID, span.Kind)
+		}
+		if span.Status != nil && !span.Status.Code.IsValid() {
+			return fmt.Errorf("span %s: unknown status code %q", span.SpanID, span.Status.Code)
+		}
+		if span.ProcessID != "" && trace.ProcessByID(span.ProcessID) == nil {
+			return fmt.Errorf("span %s: unknown process %q", span.SpanID, span.ProcessID)
+		}
+		if err := checkValueTypes(span.Tags); err != nil {
+			return fmt.Errorf("span %s: %w", span.SpanID, err)
+		}
+		for _, log := range span.Logs {
+			if err := checkValueTypes(log.Fields); err != nil {
+				return fmt.Errorf("span %s: %w", span.SpanID, err)
+			}
+		}
+	}
+	for _, process := range trace.Processes {
+		if process == nil {
+			return fmt.Errorf("trace %s: null process", trace.TraceID)
+		}
+		if err := checkValueTypes(process.Tags); err != nil {
+			return fmt.Errorf("process %s: %w", process.ServiceName, err)
+		}
+	}
+	return nil
+}
+
+// checkValueTypes rejects unknown value types
+func checkValueTypes(kvs []KeyValue) error {
+	for _, kv := range kvs {
+		switch kv.VType {
+		case StringType, BoolType, Int64Type, Float64Type, BinaryType:
+		default:
+			return fmt.Errorf("tag %q has unknown type %q", kv.Key, kv.VType)
+		}
+	}
+	return nil
+}
+
+// uiResponse is the jaeger-query /api/traces response envelope
+type uiResponse struct {
+	Data   []uiTrace `json:"data"`
+	Total  int       `json:"total"`
+	Limit  int       `json:"limit"`
+	Offset int       `json:"offset"`
+	Errors []uiError `json:"errors"`
+}
+
+type uiError struct {
+	Code    int    `json:"code,omitempty"`
+	Msg     string `json:"msg"`
+	TraceID string `json:"traceID,omitempty"`
+}
+
+type uiTrace struct {
+	TraceID   string               `json:"traceID"`
+	Spans     []uiSpan             `json:"spans"`
+	Processes map[string]uiProcess `json:"processes"`
+	Warnings  []string             `json:"warnings"`
+}
+
+type uiSpan struct {
+	TraceID       string        `json:"traceID"`
+	SpanID        string        `json:"spanID"`
+	Flags         uint32        `json:"flags,omitempty"`
+	OperationName string        `json:"operationName"`
+	References    []uiReference `json:"references"`
+	StartTime     int64         `json:"startTime"` // Microseconds since epoch
+	Duration      int64         `json:"duration"`  // Microseconds
+	Tags          []uiKeyValue  `json:"tags"`
+	Logs          []uiLog       `json:"logs"`
+	ProcessID     string        `json:"processID"`
+	Warnings      []string      `json:"warnings"`
+}
+
+type uiReference struct {
+	RefType RefType `json:"refType"`
+	TraceID string  `json:"traceID"`
+	SpanID  string  `json:"spanID"`
+}
+
+type uiProcess struct {
+	ServiceName string       `json:"serviceName"`
+	Tags        []uiKeyValue `json:"tags"`
+}
+
+type uiLog struct {
+	Timestamp int64        `json:"timestamp"`
+	Fields    []uiKeyValue `json:"fields"`
+}
+
+type uiKeyValue struct {
+	Key   string          `json:"key"`
+	Type  ValueType       `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// toUITrace converts a trace. Spans with a Process but no resolvable
+// ProcessID get one, a ParentSpanID not already referenced becomes a
+// leading CHILD_OF reference, and Kind, Status and TraceState become
+// tags (see Span.ConventionTags).
+func toUITrace(trace *Trace) uiTrace {
+	// Work on a copy of the process list so the trace is left untouched
+	processes := &Trace{Processes: append([]*Process(nil), trace.Processes...)}
+
+	ui := uiTrace{
+		TraceID:  trace.TraceID.String(),
+		Spans:    make([]uiSpan, len(trace.Spans)),
+		Warnings: trace.Warnings,
+	}
+	for i, span := range trace.Spans {
+		processID := span.ProcessID
+		if span.Process != nil && processes.ProcessByID(processID) == nil {
+			processID = processes.AddProcess(span.Process)
+		}
+
+		var refs []uiReference
+		hasParentRef := false
+		for _, ref := range span.References {
+			hasParentRef = hasParentRef || ref.SpanID == span.ParentSpanID
+		}
+		if span.ParentSpanID.IsValid() && !hasParentRef {
+			refs = append(refs, uiReference{RefType: ChildOf, TraceID: span.TraceID.String(), SpanID: span.ParentSpanID.String()})
+		}
+		for _, ref := range span.References {
+			refs = append(refs, uiReference{RefType: ref.RefType, TraceID: ref.TraceID.String(), SpanID: ref.SpanID.String()})
+		}
+
+		logs := make([]uiLog, len(span.Logs))
+		for j, log := range span.Logs {
+			logs[j] = uiLog{Timestamp: log.Timestamp.UnixMicro(), Fields: toUIKeyValues(log.Fields)}
+		}
+
+		ui.Spans[i] = uiSpan{
+			TraceID:       span.TraceID.String(),
+			SpanID:        span.SpanID.String(),
+			Flags:         span.Flags,
+			OperationName: span.OperationName,
+			References:    refs,
+			StartTime:     span.StartTime.UnixMicro(),
+			Duration:      span.Duration.Microseconds(),
+			Tags:          toUIKeyValues(span.ConventionTags()),
+			Logs:          logs,
+			ProcessID:     processID,
+			Warnings:      span.Warnings,
+		}
+	}
+
+	ui.Processes = make(map[string]uiProcess, len(processes.Processes))
+	for i, process := range processes.Processes {
+		ui.Processes[ProcessID(i)] = uiProcess{ServiceName: process.ServiceName, Tags: toUIKeyValues(process.Tags)}
+	}
+	return ui
+}
+
+// fromUITrace converts a trace back. The first reference is taken as the
+// parent if it is a CHILD_OF reference within the trace, and convention
+// tags are promoted to fields (see Span.PromoteTags).
+func fromUITrace(ui uiTrace, strict bool) (*Trace, error) {
+	traceID, err := ParseTraceID(ui.TraceID)
+	if err != nil && (strict || ui.TraceID != "") {
+		return nil, err
+	}
+	trace := NewTrace(traceID)
+	trace.Warnings = ui.Warnings
+
+	// Processes are numbered in ProcessID order so that IDs are kept
+	ids := make(map[string]string, len(ui.Processes))
+	for i := 0; len(ids) < len(ui.Processes); i++ {
+		id := ProcessID(i)
+		uiProcess, ok := ui.Processes[id]
+		if !ok {
+			break
+		}
+		process, err := fromUIProcess(uiProcess, strict)
+		if err != nil {
+			return nil, err
+		}
+		trace.Processes = append(trace.Processes, process)
+		ids[id] = id
+	}
+	// Other keys (e.g. from other producers) are appended
+	for id, uiProcess := range ui.Processes {
+		if _, ok := ids[id]; ok {
+			continue
+		}
+		process, err := fromUIProcess(uiProcess, strict)
+		if err != nil {
+			return nil, err
+		}
+		trace.Processes = append(trace.Processes, process)
+		ids[id] = ProcessID(len(trace.Processes) - 1)
+	}
+
+	for _, uiSpan := range ui.Spans {
+		span, err := fromUISpan(uiSpan, strict)
+		if err != nil {
+			return nil, err
+		}
+		if strict && span.TraceID != trace.TraceID {
+			return nil, fmt.Errorf("trace %s: span %s belongs to trace %s", trace.TraceID, span.SpanID, span.TraceID)
+		}
+		if uiSpan.ProcessID != "" {
+			id, ok := ids[uiSpan.ProcessID]
+			if !ok && strict {
+				return nil, fmt.Errorf("span %s: unknown process %q", span.SpanID, uiSpan.ProcessID)
+			}
+			if ok {
+				span.ProcessID = id
+				span.Process = trace.ProcessByID(id)
+			}
+		}
+		trace.Spans = append(trace.Spans, span)
+	}
+	if !trace.TraceID.IsValid() && len(trace.Spans) > 0 {
+		trace.TraceID = trace.Spans[0].TraceID
+	}
+	return trace, nil
+}
+
+func fromUISpan(ui uiSpan, strict bool) (*Span, error) {
+	var span Span
+	var err error
+	if span.TraceID, err = ParseTraceID(ui.TraceID); err != nil {
+		return nil, err
+	}
+	if span.SpanID, err = ParseSpanID(ui.SpanID); err != nil {
+		return nil, err
+	}
+	span.Flags = ui.Flags
+	span.OperationName = ui.OperationName
+	span.StartTime = time.UnixMicro(ui.StartTime).UTC()
+	span.Duration = time.Duration(ui.Duration) * time.Microsecond
+	span.Warnings = ui.Warnings
+
+	for i, uiRef := range ui.References {
+		ref := Reference{RefType: uiRef.RefType}
+		if ref.TraceID, err = ParseTraceID(uiRef.TraceID); err != nil {
+			return nil, err
+		}
+		if ref.SpanID, err = ParseSpanID(uiRef.SpanID); err != nil {
+			return nil, err
+		}
+		if strict && ref.RefType != ChildOf && ref.RefType != FollowsFrom {
+			return nil, fmt.Errorf("span %s: unknown reference type %q", span.SpanID, ref.RefType)
+		}
+		if i == 0 && ref.RefType == ChildOf && ref.TraceID == span.TraceID {
+			span.ParentSpanID = ref.SpanID
+			continue
+		}
+		span.References = append(span.References, ref)
+	}
+
+	if span.Tags, err = fromUIKeyValues(ui.Tags, strict); err != nil {
+		return nil, fmt.Errorf("span %s: %w", span.SpanID, err)
+	}
+	for _, uiLog := range ui.Logs {
+		fields, err := fromUIKeyValues(uiLog.Fields, strict)
+		if err != nil {
+			return nil, fmt.Errorf("span %s: %w", span.SpanID, err)
+		}
+		span.Logs = append(span.Logs, Log{Timestamp: time.UnixMicro(uiLog.Timestamp).UTC(), Fields: fields})
+	}
+	span.PromoteTags()
+	return &span, nil
+}
+
+func fromUIProcess(ui uiProcess, strict bool) (*Process, error) {
+	tags, err := fromUIKeyValues(ui.Tags, strict)
+	if err != nil {
+		return nil, fmt.Errorf("process %s: %w", ui.ServiceName, err)
+	}
+	return &Process{ServiceName: ui.ServiceName, Tags: tags}, nil
+}
+
+func toUIKeyValues(kvs []KeyValue) []uiKeyValue {
+	ui := make([]uiKeyValue, len(kvs))
+	for i, kv := range kvs {
+		var value interface{}
+		switch kv.VType {
+		case BoolType:
+			value = kv.VBool
+		case Int64Type:
+			value = kv.VInt64
+		case Float64Type:
+			value = kv.VFloat64
+		case BinaryType:
+			value = kv.VBinary // Base64, as jaeger-query writes it
+		default:
+			value = kv.VStr
+		}
+		data, _ := json.Marshal(value)
+		ui[i] = uiKeyValue{Key: kv.Key, Type: kv.VType, Value: data}
+	}
+	return ui
+}
+
+// fromUIKeyValues converts tags back. Outside strict mode, values of the
+// wrong JSON type are parsed from strings where possible, and values of
+// unknown types are kept as strings.
+func fromUIKeyValues(ui []uiKeyValue, strict bool) ([]KeyValue, error) {
+	var kvs []KeyValue
+	for _, u := range ui {
+		kv := KeyValue{Key: u.Key, VType: u.Type}
+		var err error
+		switch u.Type {
+		case StringType:
+			err = json.Unmarshal(u.Value, &kv.VStr)
+		case BoolType:
+			if err = json.Unmarshal(u.Value, &kv.VBool); err != nil && !strict {
+				kv.VBool, err = strconv.ParseBool(rawString(u.Value))
+			}
+		case Int64Type:
+			if err = json.Unmarshal(u.Value, &kv.VInt64); err != nil && !strict {
+				kv.VInt64, err = strconv.ParseInt(rawString(u.Value), 10, 64)
+			}
+		case Float64Type:
+			if err = json.Unmarshal(u.Value, &kv.VFloat64); err != nil && !strict {
+				kv.VFloat64, err = strconv.ParseFloat(rawString(u.Value), 64)
+			}
+		case BinaryType:
+			var encoded string
+			if err = json.Unmarshal(u.Value, &encoded); err == nil {
+				kv.VBinary, err = base64.StdEncoding.DecodeString(encoded)
+			}
+		default:
+			if strict {
+				return nil, fmt.Errorf("tag %q has unknown type %q", u.Key, u.Type)
+			}
+			kv.VType, kv.VStr = StringType, rawString(u.Value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tag %q: invalid %s value %s", u.Key, u.Type, u.Value)
+		}
+		kvs = append(kvs, kv)
+	}
+	return kvs, nil
+}
+
+// rawString returns a JSON string's content, or the raw JSON otherwise
+func rawString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
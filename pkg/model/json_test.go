@@ -0,0 +1,255 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jsonTestTrace builds a trace using every field the JSON formats carry
+func jsonTestTrace() *Trace {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 123456000, time.UTC)
+	traceID := TraceID{High: 0xabc, Low: 0xdef}
+
+	trace := NewTrace(traceID)
+	trace.AddSpan(&Span{
+		TraceID:       traceID,
+		SpanID:        1,
+		OperationName: "GET /cart",
+		Flags:         1,
+		Kind:          SpanKindServer,
+		Status:        &Status{Code: StatusError, Message: "upstream timeout"},
+		TraceState:    "rojo=00f067aa0ba902b7",
+		StartTime:     start,
+		Duration:      250 * time.Millisecond,
+		Tags: []KeyValue{
+			{Key: "http.method", VType: StringType, VStr: "GET"},
+			{Key: "cache.hit", VType: BoolType, VBool: true},
+			{Key: "http.status_code", VType: Int64Type, VInt64: 503},
+			{Key: "sample.rate", VType: Float64Type, VFloat64: 0.25},
+			{Key: "payload", VType: BinaryType, VBinary: []byte{0, 1, 0xff}},
+		},
+		Logs: []Log{{
+			Timestamp: start.Add(time.Millisecond),
+			Fields:    []KeyValue{{Key: "event", VType: StringType, VStr: "retry"}},
+		}},
+		Process:  &Process{ServiceName: "frontend", Tags: []KeyValue{{Key: "hostname", VType: StringType, VStr: "web-1"}}},
+		Warnings: []string{"clock skew adjusted"},
+	})
+	trace.AddSpan(&Span{
+		TraceID:       traceID,
+		SpanID:        2,
+		ParentSpanID:  1,
+		OperationName: "SELECT",
+		StartTime:     start.Add(10 * time.Millisecond),
+		Duration:      5 * time.Millisecond,
+		References:    []Reference{{RefType: FollowsFrom, TraceID: TraceID{Low: 9}, SpanID: 7}},
+		Process:       &Process{ServiceName: "cart"},
+	})
+	return trace
+}
+
+func TestSpanIDJSON(t *testing.T) {
+	data, err := json.Marshal(SpanID(42))
+	require.NoError(t, err)
+	assert.Equal(t, `"000000000000002a"`, string(data))
+
+	var id SpanID
+	require.NoError(t, json.Unmarshal(data, &id))
+	assert.Equal(t, SpanID(42), id)
+
+	// Earlier versions wrote numbers
+	require.NoError(t, json.Unmarshal([]byte(`43`), &id))
+	assert.Equal(t, SpanID(43), id)
+
+	assert.Error(t, json.Unmarshal([]byte(`"xyz"`), &id))
+}
+
+func TestNativeJSONRoundTrip(t *testing.T) {
+	trace := jsonTestTrace()
+
+	data, err := MarshalTraces([]*Trace{trace}, JSONNative)
+	require.NoError(t, err)
+
+	decoded, err := UnmarshalTraces(data, JSONNative, true)
+	require.NoError(t, err)
+	require.Len(t, decoded, 1)
+	assert.Equal(t, trace, decoded[0])
+	// Spans share the trace's processes again
+	assert.Same(t, decoded[0].Processes[0], decoded[0].Spans[0].Process)
+}
+
+func TestJaegerUIJSONRoundTrip(t *testing.T) {
+	trace := jsonTestTrace()
+
+	data, err := MarshalTraces([]*Trace{trace}, JSONJaegerUI)
+	require.NoError(t, err)
+
+	decoded, err := UnmarshalTraces(data, JSONJaegerUI, true)
+	require.NoError(t, err)
+	require.Len(t, decoded, 1)
+	assert.Equal(t, trace, decoded[0])
+}
+
+func TestJaegerUIJSONErrorTagRoundTrip(t *testing.T) {
+	// A span from Jaeger with only error=true, before any other tag
+	trace := &Trace{TraceID: TraceID{Low: 1}}
+	trace.AddSpan(&Span{
+		TraceID: TraceID{Low: 1},
+		SpanID:  1,
+		Tags:    []KeyValue{Bool(TagError, true), String("http.method", "GET")},
+		Process: &Process{ServiceName: "api"},
+	})
+	data, err := MarshalTraces([]*Trace{trace}, JSONJaegerUI)
+	require.NoError(t, err)
+
+	decoded, err := UnmarshalTraces(data, JSONJaegerUI, true)
+	require.NoError(t, err)
+	span := decoded[0].Spans[0]
+	assert.Equal(t, &Status{Code: StatusError}, span.Status)
+
+	again, err := MarshalTraces(decoded, JSONJaegerUI)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(again))
+	assert.NotContains(t, string(again), TagOTelStatusCode)
+}
+
+func TestNativeJSONOmitsUnsetStatus(t *testing.T) {
+	trace := &Trace{TraceID: TraceID{Low: 1}, Spans: []*Span{{TraceID: TraceID{Low: 1}, SpanID: 1}}}
+	data, err := MarshalTraces([]*Trace{trace}, JSONNative)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"status"`)
+}
+
+func TestJaegerUIJSONShape(t *testing.T) {
+	data, err := MarshalTraces([]*Trace{jsonTestTrace()}, JSONJaegerUI)
+	require.NoError(t, err)
+
+	var response struct {
+		Data []struct {
+			TraceID string `json:"traceID"`
+			Spans   []struct {
+				SpanID     string `json:"spanID"`
+				StartTime  int64  `json:"startTime"`
+				Duration   int64  `json:"duration"`
+				ProcessID  string `json:"processID"`
+				References []struct {
+					RefType string `json:"refType"`
+					SpanID  string `json:"spanID"`
+				} `json:"references"`
+				Tags []struct {
+					Key   string      `json:"key"`
+					Type  string      `json:"type"`
+					Value interface{} `json:"value"`
+				} `json:"tags"`
+			} `json:"spans"`
+			Processes map[string]struct {
+				ServiceName string `json:"serviceName"`
+			} `json:"processes"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(data, &response))
+	require.Len(t, response.Data, 1)
+
+	trace := response.Data[0]
+	assert.Equal(t, "0000000000000abc0000000000000def", trace.TraceID)
+	assert.Equal(t, "frontend", trace.Processes["p1"].ServiceName)
+	assert.Equal(t, "cart", trace.Processes["p2"].ServiceName)
+
+	root, child := trace.Spans[0], trace.Spans[1]
+	assert.Equal(t, "0000000000000001", root.SpanID)
+	assert.Equal(t, time.Date(2024, 3, 1, 12, 0, 0, 123456000, time.UTC).UnixMicro(), root.StartTime)
+	assert.Equal(t, int64(250000), root.Duration)
+	assert.Equal(t, "p1", root.ProcessID)
+	assert.Equal(t, float64(503), root.Tags[2].Value)
+	assert.Equal(t, "AAH/", root.Tags[4].Value)
+
+	// Kind, status and trace state are written as tags
+	tagValues := map[string]interface{}{}
+	for _, tag := range root.Tags {
+		tagValues[tag.Key] = tag.Value
+	}
+	assert.Equal(t, "server", tagValues[TagSpanKind])
+	assert.Equal(t, "ERROR", tagValues[TagOTelStatusCode])
+	assert.Equal(t, "upstream timeout", tagValues[TagOTelStatusDescription])
+	assert.Equal(t, true, tagValues[TagError])
+	assert.Equal(t, "rojo=00f067aa0ba902b7", tagValues[TagW3CTraceState])
+
+	require.Len(t, child.References, 2)
+	assert.Equal(t, "CHILD_OF", child.References[0].RefType)
+	assert.Equal(t, "0000000000000001", child.References[0].SpanID)
+	assert.Equal(t, "p2", child.ProcessID)
+}
+
+func TestJaegerUIJSONAssignsProcessIDs(t *testing.T) {
+	// Spans built without Trace.AddSpan have a Process but no ProcessID
+	trace := &Trace{TraceID: TraceID{Low: 1}, Spans: []*Span{
+		{TraceID: TraceID{Low: 1}, SpanID: 1, Process: &Process{ServiceName: "a"}},
+		{TraceID: TraceID{Low: 1}, SpanID: 2, Process: &Process{ServiceName: "a"}},
+	}}
+
+	data, err := MarshalTraces([]*Trace{trace}, JSONJaegerUI)
+	require.NoError(t, err)
+	assert.Empty(t, trace.Processes, "the trace must not be modified")
+
+	decoded, err := UnmarshalTraces(data, JSONJaegerUI, true)
+	require.NoError(t, err)
+	require.Len(t, decoded[0].Processes, 1)
+	assert.Equal(t, "p1", decoded[0].Spans[1].ProcessID)
+}
+
+func TestUnmarshalTracesStrict(t *testing.T) {
+	tests := []struct {
+		name   string
+		format JSONFormat
+		data   string
+	}{
+		{"native unknown field", JSONNative, `[{"traceId":"1","spans":[],"extra":1}]`},
+		{"native trailing data", JSONNative, `[{"traceId":"1","spans":[]}] []`},
+		{"native missing trace ID", JSONNative, `[{"spans":[]}]`},
+		{"native span of another trace", JSONNative, `[{"traceId":"1","spans":[{"traceId":"2","spanId":"1"}]}]`},
+		{"native dangling process", JSONNative, `[{"traceId":"1","spans":[{"traceId":"1","spanId":"1","processId":"p3"}]}]`},
+		{"native unknown kind", JSONNative, `[{"traceId":"1","spans":[{"traceId":"1","spanId":"1","kind":"rpc"}]}]`},
+		{"native unknown value type", JSONNative, `[{"traceId":"1","spans":[{"traceId":"1","spanId":"1","tags":[{"key":"k","vType":"map"}]}]}]`},
+		{"ui unknown field", JSONJaegerUI, `{"data":[{"traceID":"1","spans":[],"processes":{},"foo":1}]}`},
+		{"ui dangling process", JSONJaegerUI, `{"data":[{"traceID":"1","spans":[{"traceID":"1","spanID":"1","processID":"p9"}],"processes":{}}]}`},
+		{"ui value of wrong type", JSONJaegerUI, `{"data":[{"traceID":"1","spans":[{"traceID":"1","spanID":"1","tags":[{"key":"n","type":"int64","value":"5"}]}],"processes":{}}]}`},
+		{"ui errors", JSONJaegerUI, `{"data":[],"errors":[{"code":500,"msg":"boom"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UnmarshalTraces([]byte(tt.data), tt.format, true)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestUnmarshalTracesLenient(t *testing.T) {
+	data := `{"data":[{"spans":[{"traceID":"1","spanID":"1","extra":true,"processID":"p9","tags":[
+		{"key":"n","type":"int64","value":"5"},
+		{"key":"m","type":"map","value":{"a":1}}
+	]}],"processes":{}}]}`
+
+	traces, err := UnmarshalTraces([]byte(data), JSONJaegerUI, false)
+	require.NoError(t, err)
+	require.Len(t, traces, 1)
+	assert.Equal(t, TraceID{Low: 1}, traces[0].TraceID)
+
+	span := traces[0].Spans[0]
+	assert.Nil(t, span.Process)
+	assert.Equal(t, []KeyValue{
+		{Key: "n", VType: Int64Type, VInt64: 5},
+		{Key: "m", VType: StringType, VStr: `{"a":1}`},
+	}, span.Tags)
+}
+
+func TestMarshalTracesUnknownFormat(t *testing.T) {
+	_, err := MarshalTraces(nil, "zipkin")
+	assert.Error(t, err)
+	_, err = UnmarshalTraces([]byte(`[]`), "zipkin", false)
+	assert.Error(t, err)
+}
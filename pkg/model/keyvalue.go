@@ -0,0 +1,170 @@
+package model
+
+import (
+	"encoding/base64"
+	"math"
+	"strconv"
+)
+
+// String returns a string KeyValue
+func String(key, value string) KeyValue {
+	return KeyValue{Key: key, VType: StringType, VStr: value}
+}
+
+// Int64 returns an int64 KeyValue
+func Int64(key string, value int64) KeyValue {
+	return KeyValue{Key: key, VType: Int64Type, VInt64: value}
+}
+
+// Float64 returns a float64 KeyValue
+func Float64(key string, value float64) KeyValue {
+	return KeyValue{Key: key, VType: Float64Type, VFloat64: value}
+}
+
+// Bool returns a bool KeyValue
+func Bool(key string, value bool) KeyValue {
+	return KeyValue{Key: key, VType: BoolType, VBool: value}
+}
+
+// Binary returns a binary KeyValue
+func Binary(key string, value []byte) KeyValue {
+	return KeyValue{Key: key, VType: BinaryType, VBinary: value}
+}
+
+// AsString renders the value as a string; binary values are base64
+func (kv KeyValue) AsString() string {
+	switch kv.VType {
+	case BoolType:
+		return strconv.FormatBool(kv.VBool)
+	case Int64Type:
+		return strconv.FormatInt(kv.VInt64, 10)
+	case Float64Type:
+		return strconv.FormatFloat(kv.VFloat64, 'g', -1, 64)
+	case BinaryType:
+		return base64.StdEncoding.EncodeToString(kv.VBinary)
+	default:
+		return kv.VStr
+	}
+}
+
+// AsInt64 returns the value as an int64. Whole floats and numeric
+// strings convert; other values report false.
+func (kv KeyValue) AsInt64() (int64, bool) {
+	switch kv.VType {
+	case Int64Type:
+		return kv.VInt64, true
+	case Float64Type:
+		if kv.VFloat64 == math.Trunc(kv.VFloat64) && math.Abs(kv.VFloat64) < math.MaxInt64 {
+			return int64(kv.VFloat64), true
+		}
+	case StringType:
+		if v, err := strconv.ParseInt(kv.VStr, 10, 64); err == nil {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// AsFloat64 returns the value as a float64. Integers and numeric strings
+// convert; other values report false.
+func (kv KeyValue) AsFloat64() (float64, bool) {
+	switch kv.VType {
+	case Float64Type:
+		return kv.VFloat64, true
+	case Int64Type:
+		return float64(kv.VInt64), true
+	case StringType:
+		if v, err := strconv.ParseFloat(kv.VStr, 64); err == nil {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// AsBool returns the value as a bool. Strings accepted by
+// strconv.ParseBool convert; other values report false.
+func (kv KeyValue) AsBool() (bool, bool) {
+	switch kv.VType {
+	case BoolType:
+		return kv.VBool, true
+	case StringType:
+		if v, err := strconv.ParseBool(kv.VStr); err == nil {
+			return v, true
+		}
+	}
+	return false, false
+}
+
+// Tag returns the first span tag with the given key
+func (s *Span) Tag(key string) (KeyValue, bool) {
+	return lookupTag(s.Tags, key)
+}
+
+// SetTag replaces the first span tag with kv's key, or appends kv
+func (s *Span) SetTag(kv KeyValue) {
+	for i := range s.Tags {
+		if s.Tags[i].Key == kv.Key {
+			s.Tags[i] = kv
+			return
+		}
+	}
+	s.Tags = append(s.Tags, kv)
+}
+
+// Tag returns the first process tag with the given key
+func (p *Process) Tag(key string) (KeyValue, bool) {
+	return lookupTag(p.Tags, key)
+}
+
+func lookupTag(tags []KeyValue, key string) (KeyValue, bool) {
+	for _, tag := range tags {
+		if tag.Key == key {
+			return tag, true
+		}
+	}
+	return KeyValue{}, false
+}
+
+// TagIndex speeds up repeated lookups on a span with many tags, where
+// Span.Tag scans. It stays valid while tags change only through its
+// SetTag; call Reindex after changing Span.Tags directly.
+type TagIndex struct {
+	span      *Span
+	positions map[string]int
+}
+
+// IndexTags builds a tag index for the span
+func (s *Span) IndexTags() *TagIndex {
+	index := &TagIndex{span: s}
+	index.Reindex()
+	return index
+}
+
+// Reindex rebuilds the index from Span.Tags
+func (x *TagIndex) Reindex() {
+	x.positions = make(map[string]int, len(x.span.Tags))
+	for i, tag := range x.span.Tags {
+		if _, ok := x.positions[tag.Key]; !ok {
+			x.positions[tag.Key] = i
+		}
+	}
+}
+
+// Tag returns the first span tag with the given key
+func (x *TagIndex) Tag(key string) (KeyValue, bool) {
+	i, ok := x.positions[key]
+	if !ok {
+		return KeyValue{}, false
+	}
+	return x.span.Tags[i], true
+}
+
+// SetTag replaces the first span tag with kv's key, or appends kv
+func (x *TagIndex) SetTag(kv KeyValue) {
+	if i, ok := x.positions[kv.Key]; ok {
+		x.span.Tags[i] = kv
+		return
+	}
+	x.positions[kv.Key] = len(x.span.Tags)
+	x.span.Tags = append(x.span.Tags, kv)
+}
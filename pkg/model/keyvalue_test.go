@@ -0,0 +1,103 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyValueConstructors(t *testing.T) {
+	assert.Equal(t, KeyValue{Key: "k", VType: StringType, VStr: "v"}, String("k", "v"))
+	assert.Equal(t, KeyValue{Key: "k", VType: Int64Type, VInt64: 7}, Int64("k", 7))
+	assert.Equal(t, KeyValue{Key: "k", VType: Float64Type, VFloat64: 1.5}, Float64("k", 1.5))
+	assert.Equal(t, KeyValue{Key: "k", VType: BoolType, VBool: true}, Bool("k", true))
+	assert.Equal(t, KeyValue{Key: "k", VType: BinaryType, VBinary: []byte{1}}, Binary("k", []byte{1}))
+}
+
+func TestKeyValueAsString(t *testing.T) {
+	assert.Equal(t, "v", String("k", "v").AsString())
+	assert.Equal(t, "-7", Int64("k", -7).AsString())
+	assert.Equal(t, "1.5", Float64("k", 1.5).AsString())
+	assert.Equal(t, "true", Bool("k", true).AsString())
+	assert.Equal(t, "AAH/", Binary("k", []byte{0, 1, 0xff}).AsString())
+}
+
+func TestKeyValueNumericAccessors(t *testing.T) {
+	tests := []struct {
+		kv     KeyValue
+		i      int64
+		iOK    bool
+		f      float64
+		fOK    bool
+		b, bOK bool
+	}{
+		{kv: Int64("k", 3), i: 3, iOK: true, f: 3, fOK: true},
+		{kv: Float64("k", 4), i: 4, iOK: true, f: 4, fOK: true},
+		{kv: Float64("k", 4.5), f: 4.5, fOK: true},
+		{kv: String("k", "12"), i: 12, iOK: true, f: 12, fOK: true},
+		{kv: String("k", "true"), b: true, bOK: true},
+		{kv: Bool("k", false), bOK: true},
+		{kv: Binary("k", []byte("1"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kv.AsString(), func(t *testing.T) {
+			i, ok := tt.kv.AsInt64()
+			assert.Equal(t, tt.iOK, ok)
+			assert.Equal(t, tt.i, i)
+			f, ok := tt.kv.AsFloat64()
+			assert.Equal(t, tt.fOK, ok)
+			assert.Equal(t, tt.f, f)
+			b, ok := tt.kv.AsBool()
+			assert.Equal(t, tt.bOK, ok)
+			assert.Equal(t, tt.b, b)
+		})
+	}
+}
+
+func TestSpanTagAndSetTag(t *testing.T) {
+	span := &Span{Tags: []KeyValue{String(TagHTTPMethod, "GET"), Int64(TagHTTPStatusCode, 200)}}
+
+	tag, ok := span.Tag(TagHTTPStatusCode)
+	require.True(t, ok)
+	assert.Equal(t, int64(200), tag.VInt64)
+	_, ok = span.Tag(TagError)
+	assert.False(t, ok)
+
+	span.SetTag(Int64(TagHTTPStatusCode, 503))
+	span.SetTag(Bool(TagError, true))
+	assert.Equal(t, []KeyValue{
+		String(TagHTTPMethod, "GET"),
+		Int64(TagHTTPStatusCode, 503),
+		Bool(TagError, true),
+	}, span.Tags)
+
+	process := &Process{Tags: []KeyValue{String(TagHostName, "web-1")}}
+	tag, ok = process.Tag(TagHostName)
+	require.True(t, ok)
+	assert.Equal(t, "web-1", tag.VStr)
+}
+
+func TestTagIndex(t *testing.T) {
+	span := &Span{Tags: []KeyValue{String("a", "1"), String("b", "2"), String("a", "dup")}}
+	index := span.IndexTags()
+
+	tag, ok := index.Tag("a")
+	require.True(t, ok)
+	assert.Equal(t, "1", tag.VStr, "the first tag with a key wins, as in Span.Tag")
+
+	index.SetTag(String("b", "3"))
+	index.SetTag(String("c", "4"))
+	tag, ok = index.Tag("c")
+	require.True(t, ok)
+	assert.Equal(t, "4", tag.VStr)
+	assert.Equal(t, []KeyValue{String("a", "1"), String("b", "3"), String("a", "dup"), String("c", "4")}, span.Tags)
+
+	// Direct changes need a reindex
+	span.Tags = span.Tags[1:]
+	index.Reindex()
+	tag, ok = index.Tag("a")
+	require.True(t, ok)
+	assert.Equal(t, "dup", tag.VStr)
+}
@@ -0,0 +1,316 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Header names used by the propagation formats
+const (
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+	B3SingleHeader    = "b3"
+	B3TraceIDHeader   = "X-B3-TraceId"
+	B3SpanIDHeader    = "X-B3-SpanId"
+	B3ParentHeader    = "X-B3-ParentSpanId"
+	B3SampledHeader   = "X-B3-Sampled"
+	B3FlagsHeader     = "X-B3-Flags"
+	UberTraceIDHeader = "uber-trace-id"
+)
+
+// SpanContext is the part of a span that crosses process boundaries
+type SpanContext struct {
+	TraceID      TraceID
+	SpanID       SpanID
+	ParentSpanID SpanID // Only carried by B3 and uber-trace-id
+	Sampled      bool
+	Debug        bool
+	TraceState   string // W3C tracestate, passed through as is
+}
+
+// IsValid checks if both IDs are set
+func (c SpanContext) IsValid() bool {
+	return c.TraceID.IsValid() && c.SpanID.IsValid()
+}
+
+// Carrier reads and writes propagation headers. http.Header satisfies it.
+type Carrier interface {
+	Get(key string) string
+	Set(key, value string)
+}
+
+// MapCarrier is a Carrier over a plain map with lowercase keys, such as
+// gRPC metadata
+type MapCarrier map[string]string
+
+// Get returns the value of a header
+func (c MapCarrier) Get(key string) string {
+	return c[strings.ToLower(key)]
+}
+
+// Set sets a header
+func (c MapCarrier) Set(key, value string) {
+	c[strings.ToLower(key)] = value
+}
+
+// Propagator injects span contexts into carriers and extracts them back
+type Propagator interface {
+	Inject(sc SpanContext, carrier Carrier)
+	Extract(carrier Carrier) (SpanContext, error)
+}
+
+// W3CPropagator implements W3C Trace Context (traceparent and tracestate)
+type W3CPropagator struct{}
+
+// Inject writes traceparent, and tracestate if set
+func (W3CPropagator) Inject(sc SpanContext, carrier Carrier) {
+	carrier.Set(TraceParentHeader, FormatTraceParent(sc))
+	if sc.TraceState != "" {
+		carrier.Set(TraceStateHeader, sc.TraceState)
+	}
+}
+
+// Extract reads traceparent and tracestate
+func (W3CPropagator) Extract(carrier Carrier) (SpanContext, error) {
+	sc, err := ParseTraceParent(carrier.Get(TraceParentHeader))
+	if err != nil {
+		return SpanContext{}, err
+	}
+	sc.TraceState = carrier.Get(TraceStateHeader)
+	return sc, nil
+}
+
+// FormatTraceParent formats a version 00 traceparent header value
+func FormatTraceParent(sc SpanContext) string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceParent parses a traceparent header value. Versions above 00
+// are accepted as long as they start with the version 00 fields.
+func ParseTraceParent(value string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q", value)
+	}
+	version, err := strconv.ParseUint(parts[0], 16, 8)
+	if err != nil || version == 0xff || (version == 0 && len(parts) != 4) {
+		return SpanContext{}, fmt.Errorf("invalid traceparent version in %q", value)
+	}
+	if !isLowerHex(parts[1]) || !isLowerHex(parts[2]) {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q: IDs must be lowercase hex", value)
+	}
+
+	var sc SpanContext
+	if sc.TraceID, err = ParseTraceID(parts[1]); err != nil {
+		return SpanContext{}, err
+	}
+	if sc.SpanID, err = ParseSpanID(parts[2]); err != nil {
+		return SpanContext{}, err
+	}
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return SpanContext{}, fmt.Errorf("invalid traceparent flags in %q", value)
+	}
+	sc.Sampled = flags&0x01 != 0
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q: all-zero ID", value)
+	}
+	return sc, nil
+}
+
+// B3Propagator implements Zipkin B3 propagation, as the single b3 header
+// or the X-B3-* headers
+type B3Propagator struct {
+	SingleHeader bool // Inject the b3 header instead of X-B3-*
+}
+
+// Inject writes the B3 headers
+func (p B3Propagator) Inject(sc SpanContext, carrier Carrier) {
+	if p.SingleHeader {
+		carrier.Set(B3SingleHeader, FormatB3(sc))
+		return
+	}
+	carrier.Set(B3TraceIDHeader, sc.TraceID.String())
+	carrier.Set(B3SpanIDHeader, sc.SpanID.String())
+	if sc.ParentSpanID.IsValid() {
+		carrier.Set(B3ParentHeader, sc.ParentSpanID.String())
+	}
+	switch {
+	case sc.Debug:
+		carrier.Set(B3FlagsHeader, "1")
+	case sc.Sampled:
+		carrier.Set(B3SampledHeader, "1")
+	default:
+		carrier.Set(B3SampledHeader, "0")
+	}
+}
+
+// Extract reads the single b3 header, falling back to X-B3-*
+func (B3Propagator) Extract(carrier Carrier) (SpanContext, error) {
+	if value := carrier.Get(B3SingleHeader); value != "" {
+		return ParseB3(value)
+	}
+
+	var sc SpanContext
+	var err error
+	if sc.TraceID, err = ParseTraceID(carrier.Get(B3TraceIDHeader)); err != nil {
+		return SpanContext{}, err
+	}
+	if sc.SpanID, err = ParseSpanID(carrier.Get(B3SpanIDHeader)); err != nil {
+		return SpanContext{}, err
+	}
+	if parent := carrier.Get(B3ParentHeader); parent != "" {
+		if sc.ParentSpanID, err = ParseSpanID(parent); err != nil {
+			return SpanContext{}, err
+		}
+	}
+	sc.Debug = carrier.Get(B3FlagsHeader) == "1"
+	switch sampled := carrier.Get(B3SampledHeader); sampled {
+	case "1", "true":
+		sc.Sampled = true
+	case "", "0", "false":
+	default:
+		return SpanContext{}, fmt.Errorf("invalid %s %q", B3SampledHeader, sampled)
+	}
+	sc.Sampled = sc.Sampled || sc.Debug
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid B3 headers: all-zero ID")
+	}
+	return sc, nil
+}
+
+// FormatB3 formats a single b3 header value:
+// {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}
+func FormatB3(sc SpanContext) string {
+	value := sc.TraceID.String() + "-" + sc.SpanID.String()
+	switch {
+	case sc.Debug:
+		value += "-d"
+	case sc.Sampled:
+		value += "-1"
+	default:
+		value += "-0"
+	}
+	if sc.ParentSpanID.IsValid() {
+		value += "-" + sc.ParentSpanID.String()
+	}
+	return value
+}
+
+// ParseB3 parses a single b3 header value. A lone sampling state (a deny
+// or debug decision without IDs) is rejected, as there is no context.
+func ParseB3(value string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 2 || len(parts) > 4 {
+		return SpanContext{}, fmt.Errorf("invalid b3 %q", value)
+	}
+	if len(parts[0]) != 16 && len(parts[0]) != 32 {
+		return SpanContext{}, fmt.Errorf("invalid b3 %q: trace ID must be 16 or 32 hex characters", value)
+	}
+
+	var sc SpanContext
+	var err error
+	if sc.TraceID, err = ParseTraceID(parts[0]); err != nil {
+		return SpanContext{}, err
+	}
+	if sc.SpanID, err = ParseSpanID(parts[1]); err != nil {
+		return SpanContext{}, err
+	}
+	if len(parts) > 2 {
+		switch parts[2] {
+		case "1":
+			sc.Sampled = true
+		case "d":
+			sc.Sampled, sc.Debug = true, true
+		case "0":
+		default:
+			return SpanContext{}, fmt.Errorf("invalid b3 sampling state in %q", value)
+		}
+	}
+	if len(parts) > 3 {
+		if sc.ParentSpanID, err = ParseSpanID(parts[3]); err != nil {
+			return SpanContext{}, err
+		}
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid b3 %q: all-zero ID", value)
+	}
+	return sc, nil
+}
+
+// JaegerPropagator implements the Jaeger uber-trace-id header
+type JaegerPropagator struct{}
+
+// Inject writes uber-trace-id
+func (JaegerPropagator) Inject(sc SpanContext, carrier Carrier) {
+	carrier.Set(UberTraceIDHeader, FormatUberTraceID(sc))
+}
+
+// Extract reads uber-trace-id
+func (JaegerPropagator) Extract(carrier Carrier) (SpanContext, error) {
+	return ParseUberTraceID(carrier.Get(UberTraceIDHeader))
+}
+
+// FormatUberTraceID formats an uber-trace-id header value:
+// {trace-id}:{span-id}:{parent-span-id}:{flags}
+func FormatUberTraceID(sc SpanContext) string {
+	var flags uint8
+	if sc.Sampled {
+		flags |= 0x01
+	}
+	if sc.Debug {
+		flags |= 0x02
+	}
+	return fmt.Sprintf("%s:%s:%x:%x", sc.TraceID, sc.SpanID, uint64(sc.ParentSpanID), flags)
+}
+
+// ParseUberTraceID parses an uber-trace-id header value, which clients may
+// have URL-encoded
+func ParseUberTraceID(value string) (SpanContext, error) {
+	if decoded, err := url.QueryUnescape(value); err == nil {
+		value = decoded
+	}
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) != 4 {
+		return SpanContext{}, fmt.Errorf("invalid uber-trace-id %q", value)
+	}
+
+	var sc SpanContext
+	var err error
+	if sc.TraceID, err = ParseTraceID(parts[0]); err != nil {
+		return SpanContext{}, err
+	}
+	if sc.SpanID, err = ParseSpanID(parts[1]); err != nil {
+		return SpanContext{}, err
+	}
+	if sc.ParentSpanID, err = ParseSpanID(parts[2]); err != nil {
+		return SpanContext{}, err
+	}
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return SpanContext{}, fmt.Errorf("invalid uber-trace-id flags in %q", value)
+	}
+	sc.Sampled = flags&0x01 != 0
+	sc.Debug = flags&0x02 != 0
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid uber-trace-id %q: all-zero ID", value)
+	}
+	return sc, nil
+}
+
+// isLowerHex reports whether s only has lowercase hex digits
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
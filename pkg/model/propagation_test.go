@@ -0,0 +1,153 @@
+package model
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropagatorsRoundTrip(t *testing.T) {
+	contexts := map[string]SpanContext{
+		"sampled": {
+			TraceID: TraceID{High: 0x4bf92f3577b34da6, Low: 0xa3ce929d0e0e4736},
+			SpanID:  0x00f067aa0ba902b7,
+			Sampled: true,
+		},
+		"unsampled with parent": {
+			TraceID:      TraceID{Low: 42},
+			SpanID:       7,
+			ParentSpanID: 3,
+		},
+		"debug": {
+			TraceID: TraceID{High: 1, Low: 2},
+			SpanID:  3,
+			Sampled: true,
+			Debug:   true,
+		},
+	}
+
+	propagators := map[string]struct {
+		propagator Propagator
+		// Fields the format cannot carry are cleared before comparing
+		normalize func(SpanContext) SpanContext
+	}{
+		"w3c": {W3CPropagator{}, func(sc SpanContext) SpanContext {
+			sc.ParentSpanID, sc.Debug = 0, false
+			return sc
+		}},
+		"b3 single": {B3Propagator{SingleHeader: true}, func(sc SpanContext) SpanContext { return sc }},
+		"b3 multi":  {B3Propagator{}, func(sc SpanContext) SpanContext { return sc }},
+		"jaeger":    {JaegerPropagator{}, func(sc SpanContext) SpanContext { return sc }},
+	}
+
+	for pname, p := range propagators {
+		for cname, sc := range contexts {
+			t.Run(pname+"/"+cname, func(t *testing.T) {
+				for _, carrier := range []Carrier{http.Header{}, MapCarrier{}} {
+					p.propagator.Inject(sc, carrier)
+					extracted, err := p.propagator.Extract(carrier)
+					require.NoError(t, err)
+					assert.Equal(t, p.normalize(sc), extracted)
+				}
+			})
+		}
+	}
+}
+
+func TestW3CPropagatorTraceState(t *testing.T) {
+	header := http.Header{}
+	header.Set(TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	header.Set(TraceStateHeader, "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7")
+
+	sc, err := W3CPropagator{}.Extract(header)
+	require.NoError(t, err)
+	assert.Equal(t, TraceID{High: 0x4bf92f3577b34da6, Low: 0xa3ce929d0e0e4736}, sc.TraceID)
+	assert.Equal(t, SpanID(0x00f067aa0ba902b7), sc.SpanID)
+	assert.True(t, sc.Sampled)
+	assert.Equal(t, "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7", sc.TraceState)
+
+	out := http.Header{}
+	W3CPropagator{}.Inject(sc, out)
+	assert.Equal(t, header, out)
+}
+
+func TestParseTraceParentRejects(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",          // Missing flags
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",       // Uppercase
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",       // Zero trace ID
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",       // Zero span ID
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",       // Forbidden version
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", // Extra fields in 00
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",        // Short trace ID
+	} {
+		_, err := ParseTraceParent(value)
+		assert.Error(t, err, value)
+	}
+
+	// Future versions may append fields
+	sc, err := ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	require.NoError(t, err)
+	assert.True(t, sc.Sampled)
+}
+
+func TestParseB3(t *testing.T) {
+	sc, err := ParseB3("80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90")
+	require.NoError(t, err)
+	assert.Equal(t, TraceID{High: 0x80f198ee56343ba8, Low: 0x64fe8b2a57d3eff7}, sc.TraceID)
+	assert.Equal(t, SpanID(0xe457b5a2e4d86bd1), sc.SpanID)
+	assert.Equal(t, SpanID(0x05e3ac9a4f6e3b90), sc.ParentSpanID)
+	assert.True(t, sc.Sampled)
+
+	// 64-bit trace IDs and a deferred sampling decision
+	sc, err = ParseB3("64fe8b2a57d3eff7-e457b5a2e4d86bd1")
+	require.NoError(t, err)
+	assert.Equal(t, TraceID{Low: 0x64fe8b2a57d3eff7}, sc.TraceID)
+	assert.False(t, sc.Sampled)
+
+	for _, value := range []string{"0", "d", "2a-e457b5a2e4d86bd1-1", "64fe8b2a57d3eff7-e457b5a2e4d86bd1-x"} {
+		_, err := ParseB3(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestB3PropagatorExtractPrefersSingleHeader(t *testing.T) {
+	header := http.Header{}
+	header.Set(B3SingleHeader, "000000000000002a-0000000000000001-1")
+	header.Set(B3TraceIDHeader, "000000000000002b")
+	header.Set(B3SpanIDHeader, "0000000000000002")
+
+	sc, err := B3Propagator{}.Extract(header)
+	require.NoError(t, err)
+	assert.Equal(t, TraceID{Low: 42}, sc.TraceID)
+
+	header.Del(B3SingleHeader)
+	header.Set(B3SampledHeader, "true")
+	sc, err = B3Propagator{}.Extract(header)
+	require.NoError(t, err)
+	assert.Equal(t, TraceID{Low: 43}, sc.TraceID)
+	assert.True(t, sc.Sampled)
+
+	header.Set(B3SampledHeader, "maybe")
+	_, err = B3Propagator{}.Extract(header)
+	assert.Error(t, err)
+}
+
+func TestParseUberTraceID(t *testing.T) {
+	sc, err := ParseUberTraceID("2a:7:0:1")
+	require.NoError(t, err)
+	assert.Equal(t, SpanContext{TraceID: TraceID{Low: 42}, SpanID: 7, Sampled: true}, sc)
+
+	// Some clients URL-encode the header
+	sc, err = ParseUberTraceID("2a%3A7%3A3%3A3")
+	require.NoError(t, err)
+	assert.Equal(t, SpanContext{TraceID: TraceID{Low: 42}, SpanID: 7, ParentSpanID: 3, Sampled: true, Debug: true}, sc)
+
+	for _, value := range []string{"", "2a:7:0", "2a:0:0:1", "2a:7:0:zz"} {
+		_, err := ParseUberTraceID(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,48 @@
+package model
+
+// Tag keys from the Jaeger and OpenTelemetry semantic conventions
+const (
+	TagError                 = "error"
+	TagSpanKind              = "span.kind"
+	TagOTelStatusCode        = "otel.status_code"
+	TagOTelStatusDescription = "otel.status_description"
+	TagW3CTraceState         = "w3c.tracestate"
+
+	TagHTTPMethod     = "http.method"
+	TagHTTPRoute      = "http.route"
+	TagHTTPStatusCode = "http.status_code"
+	TagHTTPTarget     = "http.target"
+	TagHTTPURL        = "http.url"
+
+	TagRPCSystem  = "rpc.system"
+	TagRPCService = "rpc.service"
+	TagRPCMethod  = "rpc.method"
+
+	TagDBSystem    = "db.system"
+	TagDBName      = "db.name"
+	TagDBStatement = "db.statement"
+
+	TagMessagingSystem      = "messaging.system"
+	TagMessagingDestination = "messaging.destination"
+
+	TagPeerService = "peer.service"
+	TagNetPeerName = "net.peer.name"
+	TagNetPeerPort = "net.peer.port"
+
+	TagServiceName = "service.name"
+	TagHostName    = "host.name"
+	TagHostIP      = "host.ip"
+
+	TagSamplerType  = "sampler.type"
+	TagSamplerParam = "sampler.param"
+)
+
+// Tag keys the toolkit's receivers set on spans. They are outside the
+// semantic conventions, so they cannot clash with application tags.
+const (
+	// TagPeerIP holds the IP of the connection a span was received on
+	TagPeerIP = "jaeger-toolkit.peer.ip"
+	// TagMetadataPrefix prefixes tags holding request headers, e.g.
+	// "metadata.x-tenant"
+	TagMetadataPrefix = "metadata."
+)
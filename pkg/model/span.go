@@ -15,6 +15,9 @@ type Span struct {
 	OperationName string            `json:"operationName"`
 	References    []Reference       `json:"references,omitempty"`
 	Flags         uint32            `json:"flags"`
+	Kind          SpanKind          `json:"kind,omitempty"`
+	Status        *Status           `json:"status,omitempty"`
+	TraceState    string            `json:"traceState,omitempty"`
 	StartTime     time.Time         `json:"startTime"`
 	Duration      time.Duration     `json:"duration"`
 	Tags          []KeyValue        `json:"tags,omitempty"`
@@ -115,12 +118,9 @@ func (t TraceID) MarshalJSON() ([]byte, error) {
 	return json.Marshal(t.String())
 }
 
-// String converts TraceID to hex string
+// String converts TraceID to a 32-character hex string
 func (t TraceID) String() string {
-	if t.High == 0 {
-		return string(SpanID(t.Low).String())
-	}
-	return string(SpanID(t.High).String()) + string(SpanID(t.Low).String())
+	return fmt.Sprintf("%016x%016x", t.High, t.Low)
 }
 
 // String converts SpanID to hex string
@@ -0,0 +1,285 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SpanKind is the role of a span in a request, as in OTLP
+type SpanKind string
+
+const (
+	SpanKindUnspecified SpanKind = ""
+	SpanKindInternal    SpanKind = "internal"
+	SpanKindServer      SpanKind = "server"
+	SpanKindClient      SpanKind = "client"
+	SpanKindProducer    SpanKind = "producer"
+	SpanKindConsumer    SpanKind = "consumer"
+)
+
+// otlpSpanKinds lists kinds by their OTLP enum value
+var otlpSpanKinds = []SpanKind{
+	SpanKindUnspecified, SpanKindInternal, SpanKindServer, SpanKindClient, SpanKindProducer, SpanKindConsumer,
+}
+
+// SpanKindFromOTLP converts an OTLP Span.SpanKind value
+func SpanKindFromOTLP(v int32) (SpanKind, error) {
+	if v < 0 || int(v) >= len(otlpSpanKinds) {
+		return SpanKindUnspecified, fmt.Errorf("invalid OTLP span kind %d", v)
+	}
+	return otlpSpanKinds[v], nil
+}
+
+// OTLP returns the OTLP Span.SpanKind value of the kind
+func (k SpanKind) OTLP() int32 {
+	for i, kind := range otlpSpanKinds {
+		if kind == k {
+			return int32(i)
+		}
+	}
+	return 0
+}
+
+// IsValid reports whether the kind is one of the defined kinds
+func (k SpanKind) IsValid() bool {
+	for _, kind := range otlpSpanKinds {
+		if kind == k {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusCode is the outcome of a span, as in OTLP
+type StatusCode string
+
+const (
+	StatusUnset StatusCode = ""
+	StatusOK    StatusCode = "ok"
+	StatusError StatusCode = "error"
+)
+
+// otlpStatusCodes lists codes by their OTLP enum value
+var otlpStatusCodes = []StatusCode{StatusUnset, StatusOK, StatusError}
+
+// StatusCodeFromOTLP converts an OTLP Status.StatusCode value
+func StatusCodeFromOTLP(v int32) (StatusCode, error) {
+	if v < 0 || int(v) >= len(otlpStatusCodes) {
+		return StatusUnset, fmt.Errorf("invalid OTLP status code %d", v)
+	}
+	return otlpStatusCodes[v], nil
+}
+
+// OTLP returns the OTLP Status.StatusCode value of the code
+func (c StatusCode) OTLP() int32 {
+	for i, code := range otlpStatusCodes {
+		if code == c {
+			return int32(i)
+		}
+	}
+	return 0
+}
+
+// IsValid reports whether the code is one of the defined codes
+func (c StatusCode) IsValid() bool {
+	for _, code := range otlpStatusCodes {
+		if code == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Status is the outcome of a span with an optional error message
+type Status struct {
+	Code    StatusCode `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+// EffectiveKind returns Kind, or else the kind given by the span.kind tag
+func (s *Span) EffectiveKind() SpanKind {
+	if s.Kind != SpanKindUnspecified {
+		return s.Kind
+	}
+	if tag, ok := s.Tag(TagSpanKind); ok {
+		if kind := SpanKind(strings.ToLower(tag.VStr)); kind.IsValid() {
+			return kind
+		}
+	}
+	return SpanKindUnspecified
+}
+
+// EffectiveStatus returns Status, or else the status given by tags: an
+// otel.status_code tag, then error=true or an HTTP status of 500 or more
+// for errors.
+func (s *Span) EffectiveStatus() Status {
+	if s.Status != nil && s.Status.Code != StatusUnset {
+		return *s.Status
+	}
+	status := Status{}
+	if tag, ok := s.Tag(TagOTelStatusCode); ok {
+		switch strings.ToUpper(tag.VStr) {
+		case "ERROR":
+			status.Code = StatusError
+		case "OK":
+			status.Code = StatusOK
+		}
+		if description, ok := s.Tag(TagOTelStatusDescription); ok {
+			status.Message = description.VStr
+		}
+	}
+	if status.Code == StatusUnset {
+		if s.hasErrorTag() {
+			status.Code = StatusError
+		} else if tag, ok := s.Tag(TagHTTPStatusCode); ok && tag.VType == Int64Type && tag.VInt64 >= 500 {
+			status.Code = StatusError
+		}
+	}
+	return status
+}
+
+// hasErrorTag reports whether the span has the error=true tag
+func (s *Span) hasErrorTag() bool {
+	tag, ok := s.Tag(TagError)
+	return ok && tag.VType == BoolType && tag.VBool
+}
+
+// ConventionTags returns the span's tags plus Kind, Status and
+// TraceState written as Jaeger tags (span.kind, otel.status_code,
+// otel.status_description, error and w3c.tracestate), for formats
+// without these fields. Tags already present are kept as they are. An
+// error status without a message on a span that has error=true adds no
+// otel.status_code, since PromoteTags reads it back from error=true.
+func (s *Span) ConventionTags() []KeyValue {
+	var extra []KeyValue
+	add := func(kv KeyValue) {
+		if _, ok := s.Tag(kv.Key); !ok {
+			extra = append(extra, kv)
+		}
+	}
+
+	if s.Kind != SpanKindUnspecified {
+		add(String(TagSpanKind, string(s.Kind)))
+	}
+	var status Status
+	if s.Status != nil {
+		status = *s.Status
+	}
+	switch status.Code {
+	case StatusOK:
+		add(String(TagOTelStatusCode, "OK"))
+	case StatusError:
+		if status.Message != "" || !s.hasErrorTag() {
+			add(String(TagOTelStatusCode, "ERROR"))
+		}
+		add(Bool(TagError, true))
+	}
+	if status.Message != "" {
+		add(String(TagOTelStatusDescription, status.Message))
+	}
+	if s.TraceState != "" {
+		add(String(TagW3CTraceState, s.TraceState))
+	}
+
+	if len(extra) == 0 {
+		return s.Tags
+	}
+	tags := make([]KeyValue, 0, len(s.Tags)+len(extra))
+	return append(append(tags, s.Tags...), extra...)
+}
+
+// PromoteTags is the inverse of ConventionTags: it moves convention tags
+// into Kind, Status and TraceState where these are unset, and removes
+// them. Tags with unrecognized values stay. error=true stays unless it
+// follows otel.status_code=ERROR, where ConventionTags writes it: a
+// status read from error=true alone keeps the tag, so the span's tags
+// come back unchanged from ConventionTags.
+func (s *Span) PromoteTags() {
+	remove := make(map[string]bool)
+
+	if s.Kind == SpanKindUnspecified {
+		if tag, ok := s.Tag(TagSpanKind); ok {
+			if kind := SpanKind(strings.ToLower(tag.VStr)); kind.IsValid() && kind != SpanKindUnspecified {
+				s.Kind = kind
+				remove[TagSpanKind] = true
+			}
+		}
+	}
+
+	var status Status
+	if s.Status != nil {
+		status = *s.Status
+	}
+	fromErrorTag := false
+	if status.Code == StatusUnset {
+		if tag, ok := s.Tag(TagOTelStatusCode); ok {
+			switch strings.ToUpper(tag.VStr) {
+			case "ERROR":
+				status.Code = StatusError
+				remove[TagOTelStatusCode] = true
+				if s.hasErrorTag() && tagPosition(s.Tags, TagError) > tagPosition(s.Tags, TagOTelStatusCode) {
+					remove[TagError] = true
+				}
+			case "OK":
+				status.Code = StatusOK
+				remove[TagOTelStatusCode] = true
+			}
+		} else if s.hasErrorTag() {
+			status.Code = StatusError
+			fromErrorTag = true
+		}
+	}
+	if status.Message == "" && status.Code != StatusUnset && !fromErrorTag {
+		if tag, ok := s.Tag(TagOTelStatusDescription); ok {
+			status.Message = tag.VStr
+			remove[TagOTelStatusDescription] = true
+		}
+	}
+	if status != (Status{}) {
+		s.Status = &status
+	}
+
+	if s.TraceState == "" {
+		if tag, ok := s.Tag(TagW3CTraceState); ok {
+			s.TraceState = tag.VStr
+			remove[TagW3CTraceState] = true
+		}
+	}
+
+	if len(remove) == 0 {
+		return
+	}
+	kept := s.Tags[:0]
+	for _, tag := range s.Tags {
+		if !remove[tag.Key] {
+			kept = append(kept, tag)
+		}
+	}
+	if len(kept) == 0 {
+		kept = nil
+	}
+	s.Tags = kept
+}
+
+// tagPosition returns the index of the first tag with the key, or -1
+func tagPosition(tags []KeyValue, key string) int {
+	for i, tag := range tags {
+		if tag.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Context returns the span's propagation context. Flags bit 1 is the
+// sampled flag and bit 2 the debug flag, as in Jaeger.
+func (s *Span) Context() SpanContext {
+	return SpanContext{
+		TraceID:      s.TraceID,
+		SpanID:       s.SpanID,
+		ParentSpanID: s.ParentSpanID,
+		Sampled:      s.Flags&0x01 != 0,
+		Debug:        s.Flags&0x02 != 0,
+		TraceState:   s.TraceState,
+	}
+}
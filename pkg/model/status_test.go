@@ -0,0 +1,177 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpanKindOTLPRoundTrip(t *testing.T) {
+	for v := int32(0); v <= 5; v++ {
+		kind, err := SpanKindFromOTLP(v)
+		require.NoError(t, err)
+		assert.Equal(t, v, kind.OTLP())
+	}
+	kind, _ := SpanKindFromOTLP(2)
+	assert.Equal(t, SpanKindServer, kind)
+
+	_, err := SpanKindFromOTLP(6)
+	assert.Error(t, err)
+	assert.False(t, SpanKind("rpc").IsValid())
+}
+
+func TestStatusCodeOTLPRoundTrip(t *testing.T) {
+	for v := int32(0); v <= 2; v++ {
+		code, err := StatusCodeFromOTLP(v)
+		require.NoError(t, err)
+		assert.Equal(t, v, code.OTLP())
+	}
+	code, _ := StatusCodeFromOTLP(2)
+	assert.Equal(t, StatusError, code)
+
+	_, err := StatusCodeFromOTLP(-1)
+	assert.Error(t, err)
+}
+
+func TestEffectiveKindAndStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		span   *Span
+		kind   SpanKind
+		status Status
+	}{
+		{
+			name: "fields win over tags",
+			span: &Span{
+				Kind:   SpanKindClient,
+				Status: &Status{Code: StatusOK},
+				Tags:   []KeyValue{String(TagSpanKind, "server"), Bool(TagError, true)},
+			},
+			kind:   SpanKindClient,
+			status: Status{Code: StatusOK},
+		},
+		{
+			name: "otel status tags",
+			span: &Span{Tags: []KeyValue{
+				String(TagSpanKind, "SERVER"),
+				String(TagOTelStatusCode, "ERROR"),
+				String(TagOTelStatusDescription, "deadline exceeded"),
+			}},
+			kind:   SpanKindServer,
+			status: Status{Code: StatusError, Message: "deadline exceeded"},
+		},
+		{
+			name:   "jaeger error tag",
+			span:   &Span{Tags: []KeyValue{Bool(TagError, true)}},
+			status: Status{Code: StatusError},
+		},
+		{
+			name:   "http server error",
+			span:   &Span{Tags: []KeyValue{Int64(TagHTTPStatusCode, 502), String(TagSpanKind, "rpc")}},
+			status: Status{Code: StatusError},
+		},
+		{
+			name: "unset",
+			span: &Span{Tags: []KeyValue{Bool(TagError, false), Int64(TagHTTPStatusCode, 404)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.kind, tt.span.EffectiveKind())
+			assert.Equal(t, tt.status, tt.span.EffectiveStatus())
+		})
+	}
+}
+
+func TestConventionTagsRoundTrip(t *testing.T) {
+	spans := []*Span{
+		{
+			Kind:       SpanKindProducer,
+			Status:     &Status{Code: StatusError, Message: "broker unavailable"},
+			TraceState: "congo=t61rcWkgMzE",
+			Tags:       []KeyValue{String(TagMessagingSystem, "kafka")},
+		},
+		{Status: &Status{Code: StatusOK}},
+		{Tags: []KeyValue{String("k", "v")}},
+	}
+
+	for _, span := range spans {
+		tags := span.ConventionTags()
+		decoded := &Span{Tags: tags}
+		decoded.PromoteTags()
+		assert.Equal(t, span, decoded)
+	}
+}
+
+func TestPromoteTagsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		tags   []KeyValue
+		status *Status
+	}{
+		{
+			name:   "error tag only",
+			tags:   []KeyValue{Bool(TagError, true), String("k", "v")},
+			status: &Status{Code: StatusError},
+		},
+		{
+			name:   "error tag and description",
+			tags:   []KeyValue{Bool(TagError, true), String(TagOTelStatusDescription, "timeout")},
+			status: &Status{Code: StatusError},
+		},
+		{
+			name:   "error tag before otel status",
+			tags:   []KeyValue{Bool(TagError, true), String(TagOTelStatusCode, "ERROR"), String(TagOTelStatusDescription, "timeout")},
+			status: &Status{Code: StatusError, Message: "timeout"},
+		},
+		{
+			name: "no status",
+			tags: []KeyValue{String("k", "v")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			span := &Span{Tags: append([]KeyValue(nil), tt.tags...)}
+			span.PromoteTags()
+			assert.Equal(t, tt.status, span.Status)
+			assert.Equal(t, tt.tags, span.ConventionTags())
+		})
+	}
+}
+
+func TestConventionTagsKeepsExistingTags(t *testing.T) {
+	span := &Span{
+		Kind: SpanKindServer,
+		Tags: []KeyValue{String(TagSpanKind, "client")},
+	}
+	assert.Equal(t, span.Tags, span.ConventionTags())
+	assert.Len(t, span.Tags, 1, "the span is not modified")
+}
+
+func TestPromoteTagsKeepsUnrecognizedValues(t *testing.T) {
+	span := &Span{Tags: []KeyValue{
+		String(TagSpanKind, "rpc"),
+		String(TagOTelStatusCode, "UNKNOWN"),
+		Bool(TagError, true),
+	}}
+	span.PromoteTags()
+
+	assert.Equal(t, SpanKindUnspecified, span.Kind)
+	assert.Nil(t, span.Status)
+	assert.Len(t, span.Tags, 3)
+}
+
+func TestSpanContext(t *testing.T) {
+	span := &Span{TraceID: TraceID{Low: 1}, SpanID: 2, ParentSpanID: 3, Flags: 3, TraceState: "a=b"}
+	assert.Equal(t, SpanContext{
+		TraceID:      TraceID{Low: 1},
+		SpanID:       2,
+		ParentSpanID: 3,
+		Sampled:      true,
+		Debug:        true,
+		TraceState:   "a=b",
+	}, span.Context())
+}
@@ -0,0 +1,151 @@
+package model
+
+import (
+	"bytes"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AddSpan appends a span to the trace, deduplicating its process.
+// Spans whose process equals one already in the trace (same service
+// name and tags, in any order) share that process, and ProcessID is set
+// to its position in Processes ("p1", "p2", ...).
+func (t *Trace) AddSpan(span *Span) {
+	if span.Process != nil {
+		span.ProcessID = t.AddProcess(span.Process)
+		span.Process = t.Processes[processIndex(span.ProcessID)]
+	}
+	t.Spans = append(t.Spans, span)
+}
+
+// AddProcess adds a process unless an equal one exists and returns its
+// ID. Spans usually share their process, so the process itself is
+// looked for before equal ones.
+func (t *Trace) AddProcess(process *Process) string {
+	for i, existing := range t.Processes {
+		if existing == process {
+			return ProcessID(i)
+		}
+	}
+	for i, existing := range t.Processes {
+		if existing.Equal(process) {
+			return ProcessID(i)
+		}
+	}
+	t.Processes = append(t.Processes, process)
+	return ProcessID(len(t.Processes) - 1)
+}
+
+// ProcessByID returns the process referenced by a span's ProcessID
+func (t *Trace) ProcessByID(id string) *Process {
+	idx := processIndex(id)
+	if idx < 0 || idx >= len(t.Processes) {
+		return nil
+	}
+	return t.Processes[idx]
+}
+
+// ProcessID returns the ID of the process at index i of Trace.Processes
+func ProcessID(i int) string {
+	return "p" + strconv.Itoa(i+1)
+}
+
+// processIndex parses a ProcessID, returning -1 if malformed
+func processIndex(id string) int {
+	if !strings.HasPrefix(id, "p") {
+		return -1
+	}
+	n, err := strconv.Atoi(id[1:])
+	if err != nil || n < 1 {
+		return -1
+	}
+	return n - 1
+}
+
+// Equal reports whether two processes have the same service name and
+// the same tags, regardless of tag order. It does not allocate.
+func (p *Process) Equal(other *Process) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	if p.ServiceName != other.ServiceName || len(p.Tags) != len(other.Tags) {
+		return false
+	}
+	return sameTags(p.Tags, other.Tags)
+}
+
+// sameTags reports whether two tag lists of equal length hold the same
+// tags in any order
+func sameTags(a, b []KeyValue) bool {
+	// Tags usually come in the same order; compare the rest as multisets
+	i := 0
+	for i < len(a) && a[i].equal(b[i]) {
+		i++
+	}
+	a, b = a[i:], b[i:]
+	for _, tag := range a {
+		if countTag(a, tag) != countTag(b, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// countTag counts the tags equal to tag
+func countTag(tags []KeyValue, tag KeyValue) int {
+	n := 0
+	for _, t := range tags {
+		if t.equal(tag) {
+			n++
+		}
+	}
+	return n
+}
+
+// equal compares every field of two tags
+func (kv KeyValue) equal(other KeyValue) bool {
+	return kv.Key == other.Key && kv.VType == other.VType && kv.VStr == other.VStr &&
+		kv.VBool == other.VBool && kv.VInt64 == other.VInt64 &&
+		math.Float64bits(kv.VFloat64) == math.Float64bits(other.VFloat64) &&
+		bytes.Equal(kv.VBinary, other.VBinary)
+}
+
+// processKey renders a process into a comparison key that is equal for
+// equal processes (see Process.Equal)
+func processKey(p *Process) string {
+	parts := make([]string, len(p.Tags))
+	for i, tag := range p.Tags {
+		parts[i] = string(appendTagKey(nil, tag))
+	}
+	sort.Strings(parts)
+
+	key := appendField(nil, p.ServiceName)
+	for _, part := range parts {
+		key = append(key, part...)
+	}
+	return string(key)
+}
+
+// appendTagKey encodes every field of a tag. Strings are length-prefixed
+// and numbers end in a separator, so distinct tags never encode alike.
+func appendTagKey(b []byte, tag KeyValue) []byte {
+	b = appendField(b, tag.Key)
+	b = appendField(b, string(tag.VType))
+	b = appendField(b, tag.VStr)
+	b = strconv.AppendBool(b, tag.VBool)
+	b = append(b, ';')
+	b = strconv.AppendInt(b, tag.VInt64, 10)
+	b = append(b, ';')
+	b = strconv.AppendUint(b, math.Float64bits(tag.VFloat64), 16)
+	b = append(b, ';')
+	return appendField(b, string(tag.VBinary))
+}
+
+// appendField appends a length-prefixed string
+func appendField(b []byte, s string) []byte {
+	b = strconv.AppendInt(b, int64(len(s)), 10)
+	b = append(b, ':')
+	return append(b, s...)
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceAddSpanDeduplicatesProcesses(t *testing.T) {
+	trace := NewTrace(TraceID{Low: 1})
+
+	trace.AddSpan(&Span{SpanID: 1, Process: &Process{
+		ServiceName: "frontend",
+		Tags: []KeyValue{
+			{Key: "hostname", VType: StringType, VStr: "web-1"},
+			{Key: "pid", VType: Int64Type, VInt64: 42},
+		},
+	}})
+	// Same tags in a different order
+	trace.AddSpan(&Span{SpanID: 2, Process: &Process{
+		ServiceName: "frontend",
+		Tags: []KeyValue{
+			{Key: "pid", VType: Int64Type, VInt64: 42},
+			{Key: "hostname", VType: StringType, VStr: "web-1"},
+		},
+	}})
+	trace.AddSpan(&Span{SpanID: 3, Process: &Process{ServiceName: "frontend"}})
+	trace.AddSpan(&Span{SpanID: 4})
+
+	assert.Len(t, trace.Spans, 4)
+	assert.Len(t, trace.Processes, 2)
+	assert.Equal(t, "p1", trace.Spans[0].ProcessID)
+	assert.Equal(t, "p1", trace.Spans[1].ProcessID)
+	assert.Equal(t, "p2", trace.Spans[2].ProcessID)
+	assert.Equal(t, "", trace.Spans[3].ProcessID)
+	assert.Same(t, trace.Processes[0], trace.Spans[1].Process)
+}
+
+func TestTraceProcessByID(t *testing.T) {
+	trace := NewTrace(TraceID{Low: 1})
+	id := trace.AddProcess(&Process{ServiceName: "frontend"})
+
+	assert.Equal(t, "frontend", trace.ProcessByID(id).ServiceName)
+	assert.Nil(t, trace.ProcessByID("p2"))
+	assert.Nil(t, trace.ProcessByID("p0"))
+	assert.Nil(t, trace.ProcessByID("x1"))
+}
+
+func TestProcessEqual(t *testing.T) {
+	process := func(tags ...KeyValue) *Process { return &Process{ServiceName: "api", Tags: tags} }
+
+	a := process(String("host", "a"), Int64("pid", 1), String("host", "a"))
+	assert.True(t, a.Equal(process(Int64("pid", 1), String("host", "a"), String("host", "a"))))
+	assert.False(t, a.Equal(process(Int64("pid", 1), String("host", "a"), Int64("pid", 1))))
+	assert.False(t, a.Equal(nil))
+
+	// Fields used to run together in the comparison key
+	b := process(KeyValue{Key: "k", VType: StringType, VStr: "1", VInt64: 23})
+	c := process(KeyValue{Key: "k", VType: StringType, VStr: "12", VInt64: 3})
+	assert.False(t, b.Equal(c))
+	assert.NotEqual(t, processKey(b), processKey(c))
+	assert.Len(t, GroupByProcess([]*Span{{Process: b}, {Process: c}}), 2)
+
+	reordered := process(String("host", "a"), String("host", "a"), Int64("pid", 1))
+	assert.Zero(t, testing.AllocsPerRun(100, func() { a.Equal(reordered) }))
+}
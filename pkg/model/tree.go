@@ -0,0 +1,244 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// SpanTree is the parent/child hierarchy of a trace's spans.
+//
+// A span's parent is its ParentSpanID, else its first CHILD_OF reference
+// within the trace, else its first FOLLOWS_FROM reference within the
+// trace. Spans without a parent are Roots. Spans whose parent is not in
+// the trace are Orphans. Parent chains that loop are recorded in Cycles
+// and cut at their earliest span, which then also counts as an orphan,
+// so that walking the tree always terminates.
+type SpanTree struct {
+	Roots   []*SpanNode
+	Orphans []*SpanNode
+	Cycles  [][]*SpanNode // Each cycle in parent order, starting at the cut span
+	nodes   map[SpanID]*SpanNode
+}
+
+// SpanNode is a span within a SpanTree
+type SpanNode struct {
+	Span     *Span
+	Parent   *SpanNode
+	Children []*SpanNode // Ordered by start time
+	RefType  RefType     // How the span relates to its parent
+	Depth    int         // 0 for roots and orphans
+	parentID SpanID
+}
+
+// NewSpanTree builds the span tree of a trace. When span IDs repeat, the
+// first span with an ID is the one children attach to.
+func NewSpanTree(trace *Trace) *SpanTree {
+	tree := &SpanTree{nodes: make(map[SpanID]*SpanNode, len(trace.Spans))}
+
+	nodes := make([]*SpanNode, 0, len(trace.Spans))
+	for _, span := range trace.Spans {
+		if span == nil {
+			continue
+		}
+		node := &SpanNode{Span: span}
+		node.parentID, node.RefType = parentRef(span)
+		nodes = append(nodes, node)
+		if _, ok := tree.nodes[span.SpanID]; !ok {
+			tree.nodes[span.SpanID] = node
+		}
+	}
+
+	for _, node := range nodes {
+		if node.parentID.IsValid() {
+			node.Parent = tree.nodes[node.parentID]
+		}
+	}
+	tree.cutCycles(nodes)
+
+	for _, node := range nodes {
+		switch {
+		case node.Parent != nil:
+			node.Parent.Children = append(node.Parent.Children, node)
+		case node.parentID.IsValid():
+			tree.Orphans = append(tree.Orphans, node)
+		default:
+			tree.Roots = append(tree.Roots, node)
+		}
+	}
+	sortNodes(tree.Roots)
+	sortNodes(tree.Orphans)
+
+	tree.Walk(func(node *SpanNode) bool {
+		sortNodes(node.Children)
+		for _, child := range node.Children {
+			child.Depth = node.Depth + 1
+		}
+		return true
+	})
+	return tree
+}
+
+// parentRef returns the ID of a span's parent and how it is referenced
+func parentRef(span *Span) (SpanID, RefType) {
+	if span.ParentSpanID.IsValid() {
+		return span.ParentSpanID, ChildOf
+	}
+	for _, refType := range []RefType{ChildOf, FollowsFrom} {
+		for _, ref := range span.References {
+			if ref.RefType == refType && ref.TraceID == span.TraceID && ref.SpanID.IsValid() {
+				return ref.SpanID, refType
+			}
+		}
+	}
+	return 0, ""
+}
+
+// cutCycles finds loops in parent chains and breaks each at its
+// earliest span
+func (t *SpanTree) cutCycles(nodes []*SpanNode) {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[*SpanNode]int, len(nodes))
+
+	for _, start := range nodes {
+		var path []*SpanNode
+		node := start
+		for node != nil && state[node] == unvisited {
+			state[node] = visiting
+			path = append(path, node)
+			node = node.Parent
+		}
+
+		if node != nil && state[node] == visiting {
+			// The path from node onwards loops back to node
+			var cycle []*SpanNode
+			for i := len(path) - 1; i >= 0; i-- {
+				cycle = append([]*SpanNode{path[i]}, cycle...)
+				if path[i] == node {
+					break
+				}
+			}
+			cut := 0
+			for i, n := range cycle {
+				if nodeLess(n, cycle[cut]) {
+					cut = i
+				}
+			}
+			cycle = append(cycle[cut:], cycle[:cut]...)
+			cycle[0].Parent = nil
+			t.Cycles = append(t.Cycles, cycle)
+		}
+
+		for _, n := range path {
+			state[n] = done
+		}
+	}
+}
+
+// Node returns the node of a span ID, or nil
+func (t *SpanTree) Node(id SpanID) *SpanNode {
+	return t.nodes[id]
+}
+
+// Root returns the earliest root, or nil if the trace has none
+func (t *SpanTree) Root() *SpanNode {
+	if len(t.Roots) == 0 {
+		return nil
+	}
+	return t.Roots[0]
+}
+
+// Walk visits the tree depth-first, roots then orphans, parents before
+// children. Returning false skips a node's children.
+func (t *SpanTree) Walk(fn func(node *SpanNode) bool) {
+	var visit func(node *SpanNode)
+	visit = func(node *SpanNode) {
+		if !fn(node) {
+			return
+		}
+		for _, child := range node.Children {
+			visit(child)
+		}
+	}
+	for _, node := range t.Roots {
+		visit(node)
+	}
+	for _, node := range t.Orphans {
+		visit(node)
+	}
+}
+
+// SelfTime returns the part of the span's duration not covered by any
+// of its children. Overlapping children count once, and child time
+// outside the span is ignored.
+func (n *SpanNode) SelfTime() time.Duration {
+	start, end := n.Span.StartTime, n.Span.StartTime.Add(n.Span.Duration)
+
+	type interval struct{ start, end time.Time }
+	var intervals []interval
+	for _, child := range n.Children {
+		s, e := clip(child.Span, start, end)
+		if e.After(s) {
+			intervals = append(intervals, interval{s, e})
+		}
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start.Before(intervals[j].start) })
+
+	covered := time.Duration(0)
+	var cursor time.Time
+	for i, iv := range intervals {
+		if i == 0 || iv.start.After(cursor) {
+			cursor = iv.start
+		}
+		if iv.end.After(cursor) {
+			covered += iv.end.Sub(cursor)
+			cursor = iv.end
+		}
+	}
+	return n.Span.Duration - covered
+}
+
+// ExclusiveDuration returns the span's duration minus the durations of
+// its children, clipped to the span and not below zero. Unlike SelfTime,
+// parallel children are each subtracted.
+func (n *SpanNode) ExclusiveDuration() time.Duration {
+	start, end := n.Span.StartTime, n.Span.StartTime.Add(n.Span.Duration)
+	exclusive := n.Span.Duration
+	for _, child := range n.Children {
+		s, e := clip(child.Span, start, end)
+		if e.After(s) {
+			exclusive -= e.Sub(s)
+		}
+	}
+	if exclusive < 0 {
+		return 0
+	}
+	return exclusive
+}
+
+// clip returns a span's interval limited to [start, end]
+func clip(span *Span, start, end time.Time) (time.Time, time.Time) {
+	s, e := span.StartTime, span.StartTime.Add(span.Duration)
+	if s.Before(start) {
+		s = start
+	}
+	if e.After(end) {
+		e = end
+	}
+	return s, e
+}
+
+// sortNodes orders nodes by start time, then span ID
+func sortNodes(nodes []*SpanNode) {
+	sort.SliceStable(nodes, func(i, j int) bool { return nodeLess(nodes[i], nodes[j]) })
+}
+
+func nodeLess(a, b *SpanNode) bool {
+	if !a.Span.StartTime.Equal(b.Span.StartTime) {
+		return a.Span.StartTime.Before(b.Span.StartTime)
+	}
+	return a.Span.SpanID < b.Span.SpanID
+}
@@ -0,0 +1,151 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// treeSpan builds a span starting at a millisecond offset
+func treeSpan(id, parent SpanID, startMs, durationMs int) *Span {
+	return &Span{
+		TraceID:      TraceID{Low: 1},
+		SpanID:       id,
+		ParentSpanID: parent,
+		StartTime:    time.Unix(0, 0).Add(time.Duration(startMs) * time.Millisecond),
+		Duration:     time.Duration(durationMs) * time.Millisecond,
+	}
+}
+
+func nodeIDs(nodes []*SpanNode) []SpanID {
+	ids := make([]SpanID, len(nodes))
+	for i, node := range nodes {
+		ids[i] = node.Span.SpanID
+	}
+	return ids
+}
+
+func TestSpanTreeHierarchy(t *testing.T) {
+	followsFrom := treeSpan(5, 0, 120, 10)
+	followsFrom.References = []Reference{{RefType: FollowsFrom, TraceID: TraceID{Low: 1}, SpanID: 1}}
+	childOfRef := treeSpan(6, 0, 30, 10)
+	childOfRef.References = []Reference{
+		{RefType: FollowsFrom, TraceID: TraceID{Low: 1}, SpanID: 1},
+		{RefType: ChildOf, TraceID: TraceID{Low: 1}, SpanID: 2},
+	}
+
+	trace := &Trace{TraceID: TraceID{Low: 1}, Spans: []*Span{
+		treeSpan(3, 1, 50, 20),
+		treeSpan(1, 0, 0, 100),
+		treeSpan(2, 1, 10, 30),
+		treeSpan(4, 2, 15, 5),
+		followsFrom,
+		childOfRef,
+	}}
+
+	tree := NewSpanTree(trace)
+	require.NotNil(t, tree.Root())
+	assert.Equal(t, SpanID(1), tree.Root().Span.SpanID)
+	assert.Empty(t, tree.Orphans)
+	assert.Empty(t, tree.Cycles)
+
+	root := tree.Node(1)
+	assert.Equal(t, []SpanID{2, 3, 5}, nodeIDs(root.Children))
+	assert.Equal(t, []SpanID{4, 6}, nodeIDs(tree.Node(2).Children))
+	assert.Equal(t, FollowsFrom, tree.Node(5).RefType)
+	assert.Equal(t, ChildOf, tree.Node(6).RefType)
+	assert.Same(t, tree.Node(2), tree.Node(6).Parent)
+
+	assert.Equal(t, 0, root.Depth)
+	assert.Equal(t, 1, tree.Node(2).Depth)
+	assert.Equal(t, 2, tree.Node(4).Depth)
+
+	var visited []SpanID
+	tree.Walk(func(node *SpanNode) bool {
+		visited = append(visited, node.Span.SpanID)
+		return node.Span.SpanID != 2 // Skip 2's children
+	})
+	assert.Equal(t, []SpanID{1, 2, 3, 5}, visited)
+}
+
+func TestSpanTreeOrphansAndRoots(t *testing.T) {
+	otherTrace := treeSpan(4, 0, 0, 10)
+	otherTrace.References = []Reference{{RefType: ChildOf, TraceID: TraceID{Low: 2}, SpanID: 1}}
+
+	trace := &Trace{Spans: []*Span{
+		treeSpan(2, 99, 5, 10), // Parent never arrived
+		treeSpan(1, 0, 10, 10),
+		treeSpan(3, 2, 6, 1),
+		otherTrace, // References into other traces are ignored
+	}}
+
+	tree := NewSpanTree(trace)
+	assert.Equal(t, []SpanID{4, 1}, nodeIDs(tree.Roots))
+	assert.Equal(t, []SpanID{2}, nodeIDs(tree.Orphans))
+	assert.Equal(t, 1, tree.Node(3).Depth)
+	assert.Nil(t, tree.Node(99))
+
+	assert.Nil(t, NewSpanTree(&Trace{}).Root())
+}
+
+func TestSpanTreeCycles(t *testing.T) {
+	trace := &Trace{Spans: []*Span{
+		treeSpan(1, 3, 20, 10),
+		treeSpan(2, 1, 10, 10),
+		treeSpan(3, 2, 30, 10),
+		treeSpan(4, 3, 35, 1),
+		treeSpan(5, 5, 0, 1), // Its own parent
+	}}
+
+	tree := NewSpanTree(trace)
+	require.Len(t, tree.Cycles, 2)
+	// Each span's parent is the next one; cut at the earliest span, 2,
+	// which becomes an orphan
+	assert.Equal(t, []SpanID{2, 1, 3}, nodeIDs(tree.Cycles[0]))
+	assert.Equal(t, []SpanID{5}, nodeIDs(tree.Cycles[1]))
+	assert.Equal(t, []SpanID{5, 2}, nodeIDs(tree.Orphans))
+	assert.Empty(t, tree.Roots)
+
+	assert.Nil(t, tree.Node(2).Parent)
+	// After the cut, 2 is the parent of 3, which is the parent of 1 and 4
+	assert.Equal(t, 2, tree.Node(1).Depth)
+	assert.Equal(t, 2, tree.Node(4).Depth)
+
+	visited := 0
+	tree.Walk(func(*SpanNode) bool { visited++; return true })
+	assert.Equal(t, 5, visited)
+}
+
+func TestSpanNodeSelfTime(t *testing.T) {
+	trace := &Trace{Spans: []*Span{
+		treeSpan(1, 0, 0, 100),
+		treeSpan(2, 1, 10, 30),  // 10-40
+		treeSpan(3, 1, 20, 30),  // 20-50, overlaps 2
+		treeSpan(4, 1, 90, 30),  // 90-120, partly outside the parent
+		treeSpan(5, 1, 200, 10), // Async, after the parent
+	}}
+
+	root := NewSpanTree(trace).Root()
+	// Covered: 10-50 and 90-100
+	assert.Equal(t, 50*time.Millisecond, root.SelfTime())
+	// Subtracted: 30 + 30 + 10
+	assert.Equal(t, 30*time.Millisecond, root.ExclusiveDuration())
+
+	leaf := NewSpanTree(trace).Node(2)
+	assert.Equal(t, 30*time.Millisecond, leaf.SelfTime())
+	assert.Equal(t, 30*time.Millisecond, leaf.ExclusiveDuration())
+}
+
+func TestSpanNodeExclusiveDurationNotNegative(t *testing.T) {
+	trace := &Trace{Spans: []*Span{
+		treeSpan(1, 0, 0, 10),
+		treeSpan(2, 1, 0, 10),
+		treeSpan(3, 1, 0, 10),
+	}}
+
+	root := NewSpanTree(trace).Root()
+	assert.Equal(t, time.Duration(0), root.SelfTime())
+	assert.Equal(t, time.Duration(0), root.ExclusiveDuration())
+}
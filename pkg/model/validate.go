@@ -0,0 +1,233 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError lists the problems found in a span or trace
+type ValidationError struct {
+	Problems []string
+}
+
+// Error joins the problems
+func (e *ValidationError) Error() string {
+	return "invalid span: " + strings.Join(e.Problems, "; ")
+}
+
+// validation collects problems, fixing them when normalizing
+type validation struct {
+	fix      bool
+	problems []string // Problems left (all of them when only validating)
+	fixed    []string // Fixes made, for Warnings
+}
+
+func (v *validation) report(fixable bool, format string, args ...interface{}) bool {
+	msg := fmt.Sprintf(format, args...)
+	if v.fix && fixable {
+		v.fixed = append(v.fixed, msg)
+		return true
+	}
+	v.problems = append(v.problems, msg)
+	return false
+}
+
+func (v *validation) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
+
+// Validate checks that a span is well formed: non-zero IDs, a
+// non-negative duration, references within the span's trace, unique tag
+// keys and valid UTF-8 strings. It returns a *ValidationError listing
+// every problem. It never modifies the span.
+func (s *Span) Validate() error {
+	v := &validation{}
+	s.check(v)
+	return v.err()
+}
+
+// Normalize fixes what Validate would report where possible, recording
+// each fix in Warnings:
+//   - negative durations become 0
+//   - references with a zero trace ID get the span's; references to
+//     other traces, to span 0 or to the span itself are dropped
+//   - a ParentSpanID equal to the SpanID is cleared
+//   - later tags repeating a key are dropped, so Tag(key) is unchanged
+//   - invalid UTF-8 is replaced with U+FFFD
+//
+// Zero trace or span IDs cannot be fixed and are returned as a
+// *ValidationError.
+func (s *Span) Normalize() error {
+	v := &validation{fix: true}
+	s.check(v)
+	s.Warnings = append(s.Warnings, v.fixed...)
+	return v.err()
+}
+
+func (s *Span) check(v *validation) {
+	if !s.TraceID.IsValid() {
+		v.report(false, "trace ID is zero")
+	}
+	if !s.SpanID.IsValid() {
+		v.report(false, "span ID is zero")
+	}
+	if s.Duration < 0 && v.report(true, "negative duration %v set to 0", s.Duration) {
+		s.Duration = 0
+	}
+	if s.ParentSpanID.IsValid() && s.ParentSpanID == s.SpanID &&
+		v.report(true, "parent span ID %s equal to span ID cleared", s.ParentSpanID) {
+		s.ParentSpanID = 0
+	}
+
+	// Fixes go to a copy, so that only fixing writes to the span
+	refs := s.References[:0:0]
+	changed := false
+	for _, ref := range s.References {
+		switch {
+		case !ref.TraceID.IsValid():
+			if v.report(true, "reference to span %s without trace ID set to trace %s", ref.SpanID, s.TraceID) {
+				ref.TraceID = s.TraceID
+				changed = true
+			}
+		case ref.TraceID != s.TraceID:
+			if v.report(true, "reference to span %s in trace %s dropped: not in this trace", ref.SpanID, ref.TraceID) {
+				changed = true
+				continue
+			}
+		}
+		if !ref.SpanID.IsValid() && v.report(true, "reference to span ID zero dropped") {
+			changed = true
+			continue
+		}
+		if ref.SpanID == s.SpanID && s.SpanID.IsValid() && v.report(true, "reference to the span itself dropped") {
+			changed = true
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	if changed {
+		s.References = refs
+	}
+
+	if name := checkUTF8(v, "operation name", s.OperationName); name != s.OperationName {
+		s.OperationName = name
+	}
+	if tags := checkTags(v, "span", s.Tags); len(tags) != len(s.Tags) {
+		s.Tags = tags
+	}
+	for i := range s.Logs {
+		for j := range s.Logs[i].Fields {
+			checkKeyValueUTF8(v, "log field", &s.Logs[i].Fields[j])
+		}
+	}
+	if s.Process != nil {
+		s.Process.check(v)
+	}
+}
+
+func (p *Process) check(v *validation) {
+	if name := checkUTF8(v, "service name", p.ServiceName); name != p.ServiceName {
+		p.ServiceName = name
+	}
+	if tags := checkTags(v, "process", p.Tags); len(tags) != len(p.Tags) {
+		p.Tags = tags
+	}
+}
+
+// checkTags reports duplicate keys and invalid UTF-8 in tags. When
+// fixing, strings are repaired in place and a shorter copy is returned
+// if tags were dropped.
+func checkTags(v *validation, kind string, tags []KeyValue) []KeyValue {
+	seen := make(map[string]bool, len(tags))
+	kept := tags[:0:0]
+	for i := range tags {
+		checkKeyValueUTF8(v, kind+" tag", &tags[i])
+		if seen[tags[i].Key] {
+			if v.report(true, "duplicate %s tag %q dropped", kind, tags[i].Key) {
+				continue
+			}
+		}
+		seen[tags[i].Key] = true
+		kept = append(kept, tags[i])
+	}
+	if len(kept) == len(tags) {
+		return tags
+	}
+	return kept
+}
+
+// checkKeyValueUTF8 reports invalid UTF-8 in a key or string value,
+// writing to kv only when fixing it
+func checkKeyValueUTF8(v *validation, kind string, kv *KeyValue) {
+	if key := checkUTF8(v, kind+" key", kv.Key); key != kv.Key {
+		kv.Key = key
+	}
+	if kv.VType == StringType || kv.VType == "" {
+		if str := checkUTF8(v, fmt.Sprintf("%s %q", kind, kv.Key), kv.VStr); str != kv.VStr {
+			kv.VStr = str
+		}
+	}
+}
+
+// checkUTF8 reports invalid UTF-8, returning the repaired string when
+// fixing
+func checkUTF8(v *validation, what, s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	if v.report(true, "invalid UTF-8 in %s replaced", what) {
+		return strings.ToValidUTF8(s, "\uFFFD")
+	}
+	return s
+}
+
+// Validate checks every span of the trace (see Span.Validate), and that
+// spans belong to the trace and reference existing processes. Like
+// Span.Validate it only reads, so it can run on a shared trace. Nil
+// spans are skipped.
+func (t *Trace) Validate() error {
+	return t.check(false)
+}
+
+// Normalize normalizes every span of the trace (see Span.Normalize).
+// ProcessIDs of missing processes are reassigned from the span's Process
+// or cleared. Spans with zero IDs or of another trace are left in place
+// and returned as a *ValidationError.
+func (t *Trace) Normalize() error {
+	return t.check(true)
+}
+
+func (t *Trace) check(fix bool) error {
+	var problems []string
+	for _, span := range t.Spans {
+		if span == nil {
+			continue
+		}
+		v := &validation{fix: fix}
+		span.check(v)
+		if t.TraceID.IsValid() && span.TraceID != t.TraceID {
+			v.report(false, "trace ID %s differs from the trace's %s", span.TraceID, t.TraceID)
+		}
+		if span.ProcessID != "" && t.ProcessByID(span.ProcessID) == nil &&
+			v.report(true, "process ID %q of a missing process reassigned", span.ProcessID) {
+			span.ProcessID = ""
+			if span.Process != nil {
+				span.ProcessID = t.AddProcess(span.Process)
+			}
+		}
+		if len(v.fixed) > 0 {
+			span.Warnings = append(span.Warnings, v.fixed...)
+		}
+		for _, problem := range v.problems {
+			problems = append(problems, fmt.Sprintf("span %s: %s", span.SpanID, problem))
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
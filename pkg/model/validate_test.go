@@ -0,0 +1,144 @@
+package model
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateValidSpan(t *testing.T) {
+	trace := jsonTestTrace()
+	for _, span := range trace.Spans {
+		span.References = nil
+	}
+	assert.NoError(t, trace.Validate())
+	assert.NoError(t, trace.Spans[0].Validate())
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	span := &Span{
+		Duration:      -time.Second,
+		OperationName: "bad\xff",
+		Tags:          []KeyValue{String("k", "a"), String("k", "b")},
+	}
+
+	err := span.Validate()
+	require.Error(t, err)
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, []string{
+		"trace ID is zero",
+		"span ID is zero",
+		"negative duration -1s set to 0",
+		"invalid UTF-8 in operation name replaced",
+		`duplicate span tag "k" dropped`,
+	}, verr.Problems)
+
+	// Validate leaves the span alone
+	assert.Equal(t, -time.Second, span.Duration)
+	assert.Len(t, span.Tags, 2)
+	assert.Empty(t, span.Warnings)
+}
+
+func TestNormalizeFixesSpan(t *testing.T) {
+	traceID := TraceID{Low: 7}
+	span := &Span{
+		TraceID:       traceID,
+		SpanID:        3,
+		ParentSpanID:  3,
+		OperationName: "GET \xc3(",
+		Duration:      -5 * time.Millisecond,
+		References: []Reference{
+			{RefType: ChildOf, SpanID: 1},
+			{RefType: FollowsFrom, TraceID: TraceID{Low: 8}, SpanID: 2},
+			{RefType: FollowsFrom, TraceID: traceID},
+			{RefType: FollowsFrom, TraceID: traceID, SpanID: 3},
+		},
+		Tags: []KeyValue{String("http.method", "GET"), Int64("n", 1), String("http.method", "POST")},
+		Logs: []Log{{Fields: []KeyValue{String("event", "\xff")}}},
+		Process: &Process{
+			ServiceName: "cart",
+			Tags:        []KeyValue{String("host", "a"), String("host", "b")},
+		},
+	}
+
+	require.NoError(t, span.Normalize())
+	assert.Equal(t, time.Duration(0), span.Duration)
+	assert.Equal(t, SpanID(0), span.ParentSpanID)
+	assert.Equal(t, "GET �(", span.OperationName)
+	assert.Equal(t, []Reference{{RefType: ChildOf, TraceID: traceID, SpanID: 1}}, span.References)
+	assert.Equal(t, []KeyValue{String("http.method", "GET"), Int64("n", 1)}, span.Tags)
+	assert.Equal(t, "�", span.Logs[0].Fields[0].VStr)
+	assert.Equal(t, []KeyValue{String("host", "a")}, span.Process.Tags)
+	assert.Len(t, span.Warnings, 10)
+
+	// Normalized spans validate, and normalizing again changes nothing
+	require.NoError(t, span.Validate())
+	require.NoError(t, span.Normalize())
+	assert.Len(t, span.Warnings, 10)
+}
+
+func TestNormalizeRejectsZeroIDs(t *testing.T) {
+	span := &Span{SpanID: 1, Duration: -1}
+	err := span.Normalize()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "trace ID is zero")
+	assert.Equal(t, []string{"negative duration -1ns set to 0"}, span.Warnings)
+}
+
+func TestTraceNormalizeProcesses(t *testing.T) {
+	traceID := TraceID{Low: 1}
+	process := &Process{ServiceName: "api"}
+	trace := &Trace{TraceID: traceID, Spans: []*Span{
+		{TraceID: traceID, SpanID: 1, Process: process, ProcessID: "p4"},
+		{TraceID: traceID, SpanID: 2, ProcessID: "p2"},
+	}}
+
+	require.Error(t, trace.Validate())
+	require.NoError(t, trace.Normalize())
+	assert.Equal(t, "p1", trace.Spans[0].ProcessID)
+	assert.Same(t, process, trace.ProcessByID("p1"))
+	assert.Empty(t, trace.Spans[1].ProcessID)
+	assert.Len(t, trace.Spans[1].Warnings, 1)
+	assert.NoError(t, trace.Validate())
+}
+
+func TestTraceNormalizeRejectsForeignSpan(t *testing.T) {
+	trace := &Trace{TraceID: TraceID{Low: 1}, Spans: []*Span{
+		{TraceID: TraceID{Low: 2}, SpanID: 5},
+	}}
+	err := trace.Normalize()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "span 0000000000000005: trace ID")
+}
+
+func TestValidateOnlyReads(t *testing.T) {
+	trace := jsonTestTrace()
+	trace.Spans[0].OperationName = "bad\xff"
+	trace.Spans[0].Tags = append(trace.Spans[0].Tags, String("k", "\xff"), String("k", "b"))
+	trace.Spans[1].References = append(trace.Spans[1].References, Reference{RefType: FollowsFrom, SpanID: 1})
+
+	// Run with -race: concurrent validations of a shared trace must not
+	// write to it
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Error(t, trace.Validate())
+			assert.Error(t, trace.Spans[0].Validate())
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, "bad\xff", trace.Spans[0].OperationName)
+}
+
+func TestTraceValidateSkipsNilSpans(t *testing.T) {
+	traceID := TraceID{Low: 1}
+	trace := &Trace{TraceID: traceID, Spans: []*Span{nil, {TraceID: traceID, SpanID: 1}}}
+	assert.NoError(t, trace.Validate())
+	assert.NoError(t, trace.Normalize())
+}
@@ -26,6 +26,9 @@ type HealthCheck struct {
 	mu      sync.RWMutex
 	started bool
 
+	// Additional handlers mounted by components (e.g. Prometheus exporters)
+	handlers map[string]http.Handler
+
 	// Thresholds for degraded/unhealthy status
 	dropRateWarning    float64
 	dropRateCritical   float64
@@ -62,9 +65,23 @@ func NewHealthCheck(metrics *Metrics, config HealthCheckConfig) *HealthCheck {
 		dropRateCritical:   config.DropRateCritical,
 		errorRateWarning:   config.ErrorRateWarning,
 		errorRateCritical:  config.ErrorRateCritical,
+		handlers:           make(map[string]http.Handler),
 	}
 }
 
+// Handle mounts an additional handler on the health server.
+// Must be called before Start.
+func (h *HealthCheck) Handle(pattern string, handler http.Handler) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.started {
+		return fmt.Errorf("health check already started")
+	}
+	h.handlers[pattern] = handler
+	return nil
+}
+
 // Start starts the health check HTTP server
 func (h *HealthCheck) Start(ctx context.Context) error {
 	h.mu.Lock()
@@ -78,6 +95,9 @@ func (h *HealthCheck) Start(ctx context.Context) error {
 	mux.HandleFunc("/health", h.handleHealth)
 	mux.HandleFunc("/metrics", h.handleMetrics)
 	mux.HandleFunc("/ready", h.handleReady)
+	for pattern, handler := range h.handlers {
+		mux.Handle(pattern, handler)
+	}
 
 	h.server = &http.Server{
 		Addr:         h.addr,
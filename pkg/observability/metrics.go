@@ -1,6 +1,7 @@
 package observability
 
 import (
+	"math"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -15,6 +16,12 @@ type Metrics struct {
 	spansExported  atomic.Uint64
 	exportErrors   atomic.Uint64
 
+	// Named counters published by components (name -> *atomic.Uint64)
+	counters sync.Map
+
+	// Gauges published by components (name -> *atomic.Uint64 holding float64 bits)
+	gauges sync.Map
+
 	// Latency tracking
 	mu              sync.RWMutex
 	processingTimes []time.Duration
@@ -54,6 +61,26 @@ func (m *Metrics) RecordExportError() {
 	m.exportErrors.Add(1)
 }
 
+// AddCounter increments a named counter.
+// Safe to call from hot paths; no locks are taken.
+func (m *Metrics) AddCounter(name string, delta uint64) {
+	v, ok := m.counters.Load(name)
+	if !ok {
+		v, _ = m.counters.LoadOrStore(name, new(atomic.Uint64))
+	}
+	v.(*atomic.Uint64).Add(delta)
+}
+
+// SetGauge publishes the current value of a named gauge.
+// Safe to call from hot paths; no locks are taken.
+func (m *Metrics) SetGauge(name string, value float64) {
+	v, ok := m.gauges.Load(name)
+	if !ok {
+		v, _ = m.gauges.LoadOrStore(name, new(atomic.Uint64))
+	}
+	v.(*atomic.Uint64).Store(math.Float64bits(value))
+}
+
 // RecordProcessingTime records span processing latency
 func (m *Metrics) RecordProcessingTime(d time.Duration) {
 	m.mu.Lock()
@@ -78,8 +105,20 @@ func (m *Metrics) Snapshot() MetricsSnapshot {
 		SpansDropped:   m.spansDropped.Load(),
 		SpansExported:  m.spansExported.Load(),
 		ExportErrors:   m.exportErrors.Load(),
+		Counters:       make(map[string]uint64),
+		Gauges:         make(map[string]float64),
 	}
 
+	m.counters.Range(func(key, value any) bool {
+		snapshot.Counters[key.(string)] = value.(*atomic.Uint64).Load()
+		return true
+	})
+
+	m.gauges.Range(func(key, value any) bool {
+		snapshot.Gauges[key.(string)] = math.Float64frombits(value.(*atomic.Uint64).Load())
+		return true
+	})
+
 	// Calculate latency percentiles
 	if len(m.processingTimes) > 0 {
 		sorted := make([]time.Duration, len(m.processingTimes))
@@ -112,6 +151,8 @@ type MetricsSnapshot struct {
 	LatencyP50     time.Duration
 	LatencyP95     time.Duration
 	LatencyP99     time.Duration
+	Counters       map[string]uint64
+	Gauges         map[string]float64
 }
 
 // DropRate calculates the percentage of dropped spans
@@ -85,3 +85,30 @@ func TestMetricsBufferRotation(t *testing.T) {
 
 	assert.LessOrEqual(t, bufferSize, 10)
 }
+
+func TestMetricsGauges(t *testing.T) {
+	m := NewMetrics()
+
+	m.SetGauge("sampling.rate", 0.1)
+	m.SetGauge("sampling.rate", 0.25)
+	m.SetGauge("sampling.error_share", 0.02)
+
+	snapshot := m.Snapshot()
+
+	assert.Equal(t, 2, len(snapshot.Gauges))
+	assert.Equal(t, 0.25, snapshot.Gauges["sampling.rate"])
+	assert.Equal(t, 0.02, snapshot.Gauges["sampling.error_share"])
+}
+
+func TestMetricsCountersByName(t *testing.T) {
+	m := NewMetrics()
+
+	m.AddCounter("filter.noise.dropped.healthcheck", 1)
+	m.AddCounter("filter.noise.dropped.healthcheck", 2)
+	m.AddCounter("filter.noise.dropped.metrics", 1)
+
+	snapshot := m.Snapshot()
+
+	assert.Equal(t, uint64(3), snapshot.Counters["filter.noise.dropped.healthcheck"])
+	assert.Equal(t, uint64(1), snapshot.Counters["filter.noise.dropped.metrics"])
+}
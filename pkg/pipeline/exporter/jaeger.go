@@ -9,7 +9,7 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 )
 
-// JaegerExporter exports spans to Jaeger backend via gRPC
+// JaegerExporter exports span batches to Jaeger backend via gRPC
 type JaegerExporter struct {
 	name     string
 	endpoint string
@@ -30,8 +30,9 @@ func NewJaegerExporter(name string, config JaegerConfig) *JaegerExporter {
 	}
 }
 
-// Export sends spans to Jaeger backend
-func (e *JaegerExporter) Export(ctx context.Context, in <-chan *model.Span) error {
+// Export sends span batches to Jaeger backend. Each batch is split by
+// process and sent as one PostSpans RPC per process.
+func (e *JaegerExporter) Export(ctx context.Context, in <-chan []*model.Span) error {
 	// Establish gRPC connection
 	opts := []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
@@ -45,18 +46,20 @@ func (e *JaegerExporter) Export(ctx context.Context, in <-chan *model.Span) erro
 
 	e.conn = conn
 
-	// Process spans from channel
+	// Process batches from channel
 	for {
 		select {
-		case span, ok := <-in:
+		case spans, ok := <-in:
 			if !ok {
 				// Channel closed
 				return nil
 			}
 
-			if err := e.sendSpan(ctx, span); err != nil {
-				// Log error but continue (would emit metric in production)
-				fmt.Printf("Failed to send span: %v\n", err)
+			for _, batch := range model.GroupByProcess(spans) {
+				if err := e.sendBatch(ctx, batch); err != nil {
+					// Log error but continue (would emit metric in production)
+					fmt.Printf("Failed to send batch of %d spans: %v\n", len(batch.Spans), err)
+				}
 			}
 
 		case <-ctx.Done():
@@ -65,12 +68,10 @@ func (e *JaegerExporter) Export(ctx context.Context, in <-chan *model.Span) erro
 	}
 }
 
-// sendSpan sends a single span to Jaeger
-func (e *JaegerExporter) sendSpan(ctx context.Context, span *model.Span) error {
-	// TODO: Convert span to Jaeger protobuf format and send via gRPC
-	// For now, this is a skeleton implementation
-	_ = span
-	return nil
+// sendBatch sends one batch to Jaeger in a single RPC
+func (e *JaegerExporter) sendBatch(ctx context.Context, batch model.Batch) error {
+	var resp []byte
+	return e.conn.Invoke(ctx, postSpansMethod, encodePostSpansRequest(batch), &resp, grpc.ForceCodec(rawCodec{}))
 }
 
 // Name returns the exporter name
@@ -0,0 +1,208 @@
+package exporter
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Hand-written encoding of the Jaeger api_v2 protobuf messages
+// (model.proto and collector.proto), so that the collector can be called
+// without generated code.
+
+// postSpansMethod is the Jaeger collector RPC accepting a batch
+const postSpansMethod = "/jaeger.api_v2.CollectorService/PostSpans"
+
+// rawCodec passes pre-encoded protobuf messages through gRPC
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("raw codec: unexpected message type %T", v)
+	}
+	return b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("raw codec: unexpected message type %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string { return "proto" }
+
+// encodePostSpansRequest encodes PostSpansRequest{batch = 1}
+func encodePostSpansRequest(batch model.Batch) []byte {
+	return appendMessage(nil, 1, encodeBatch(batch))
+}
+
+// encodeBatch encodes Batch{spans = 1, process = 2}
+func encodeBatch(batch model.Batch) []byte {
+	var b []byte
+	for _, span := range batch.Spans {
+		b = appendMessage(b, 1, encodeSpan(span))
+	}
+	if batch.Process != nil {
+		b = appendMessage(b, 2, encodeProcess(batch.Process))
+	}
+	return b
+}
+
+// encodeSpan encodes a Span. The process is carried by the batch; a
+// ParentSpanID not already referenced becomes a CHILD_OF reference, and
+// Kind, Status and TraceState become tags.
+func encodeSpan(span *model.Span) []byte {
+	var b []byte
+	b = appendBytes(b, 1, span.TraceID.Bytes())
+	b = appendBytes(b, 2, span.SpanID.Bytes())
+	b = appendString(b, 3, span.OperationName)
+
+	hasParentRef := false
+	for _, ref := range span.References {
+		hasParentRef = hasParentRef || ref.SpanID == span.ParentSpanID
+		b = appendMessage(b, 4, encodeReference(ref))
+	}
+	if span.ParentSpanID.IsValid() && !hasParentRef {
+		b = appendMessage(b, 4, encodeReference(model.Reference{
+			RefType: model.ChildOf,
+			TraceID: span.TraceID,
+			SpanID:  span.ParentSpanID,
+		}))
+	}
+
+	if span.Flags != 0 {
+		b = protowire.AppendTag(b, 5, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(span.Flags))
+	}
+	b = appendMessage(b, 6, encodeTimestamp(span.StartTime))
+	b = appendMessage(b, 7, encodeDuration(span.Duration))
+	for _, tag := range span.ConventionTags() {
+		b = appendMessage(b, 8, encodeKeyValue(tag))
+	}
+	for _, log := range span.Logs {
+		b = appendMessage(b, 9, encodeLog(log))
+	}
+	for _, warning := range span.Warnings {
+		b = appendString(b, 12, warning)
+	}
+	return b
+}
+
+// encodeReference encodes SpanRef{trace_id = 1, span_id = 2, ref_type = 3}
+func encodeReference(ref model.Reference) []byte {
+	var b []byte
+	b = appendBytes(b, 1, ref.TraceID.Bytes())
+	b = appendBytes(b, 2, ref.SpanID.Bytes())
+	if ref.RefType == model.FollowsFrom {
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	return b
+}
+
+// encodeProcess encodes Process{service_name = 1, tags = 2}
+func encodeProcess(process *model.Process) []byte {
+	var b []byte
+	b = appendString(b, 1, process.ServiceName)
+	for _, tag := range process.Tags {
+		b = appendMessage(b, 2, encodeKeyValue(tag))
+	}
+	return b
+}
+
+// encodeLog encodes Log{timestamp = 1, fields = 2}
+func encodeLog(log model.Log) []byte {
+	b := appendMessage(nil, 1, encodeTimestamp(log.Timestamp))
+	for _, field := range log.Fields {
+		b = appendMessage(b, 2, encodeKeyValue(field))
+	}
+	return b
+}
+
+// encodeKeyValue encodes KeyValue{key = 1, v_type = 2, v_str = 3,
+// v_bool = 4, v_int64 = 5, v_float64 = 6, v_binary = 7}
+func encodeKeyValue(kv model.KeyValue) []byte {
+	b := appendString(nil, 1, kv.Key)
+
+	var vType uint64
+	switch kv.VType {
+	case model.BoolType:
+		vType = 1
+		if kv.VBool {
+			b = protowire.AppendTag(b, 4, protowire.VarintType)
+			b = protowire.AppendVarint(b, 1)
+		}
+	case model.Int64Type:
+		vType = 2
+		if kv.VInt64 != 0 {
+			b = protowire.AppendTag(b, 5, protowire.VarintType)
+			b = protowire.AppendVarint(b, uint64(kv.VInt64))
+		}
+	case model.Float64Type:
+		vType = 3
+		if kv.VFloat64 != 0 {
+			b = protowire.AppendTag(b, 6, protowire.Fixed64Type)
+			b = protowire.AppendFixed64(b, math.Float64bits(kv.VFloat64))
+		}
+	case model.BinaryType:
+		vType = 4
+		b = appendBytes(b, 7, kv.VBinary)
+	default:
+		b = appendString(b, 3, kv.VStr)
+	}
+	if vType != 0 {
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, vType)
+	}
+	return b
+}
+
+// encodeTimestamp encodes google.protobuf.Timestamp{seconds = 1, nanos = 2}
+func encodeTimestamp(t time.Time) []byte {
+	return encodeSecondsNanos(t.Unix(), int64(t.Nanosecond()))
+}
+
+// encodeDuration encodes google.protobuf.Duration{seconds = 1, nanos = 2}
+func encodeDuration(d time.Duration) []byte {
+	return encodeSecondsNanos(int64(d/time.Second), int64(d%time.Second))
+}
+
+func encodeSecondsNanos(seconds, nanos int64) []byte {
+	var b []byte
+	if seconds != 0 {
+		b = protowire.AppendTag(b, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(seconds))
+	}
+	if nanos != 0 {
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(nanos))
+	}
+	return b
+}
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func appendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	return appendMessage(b, num, v)
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
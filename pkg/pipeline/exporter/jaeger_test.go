@@ -0,0 +1,121 @@
+package exporter
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// fakeCollector records raw PostSpans requests
+type fakeCollector struct {
+	mu       sync.Mutex
+	methods  []string
+	requests [][]byte
+}
+
+func (c *fakeCollector) handle(srv any, stream grpc.ServerStream) error {
+	method, _ := grpc.MethodFromServerStream(stream)
+	var req []byte
+	if err := stream.RecvMsg(&req); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.methods = append(c.methods, method)
+	c.requests = append(c.requests, req)
+	c.mu.Unlock()
+	return stream.SendMsg(&[]byte{})
+}
+
+// fields decodes the top-level fields of a message
+func fields(t *testing.T, b []byte) map[protowire.Number][][]byte {
+	out := make(map[protowire.Number][][]byte)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+		var value []byte
+		switch typ {
+		case protowire.BytesType:
+			v, m := protowire.ConsumeBytes(b)
+			require.GreaterOrEqual(t, m, 0)
+			value, n = v, m
+		case protowire.VarintType:
+			v, m := protowire.ConsumeVarint(b)
+			require.GreaterOrEqual(t, m, 0)
+			value, n = protowire.AppendVarint(nil, v), m
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			require.GreaterOrEqual(t, n, 0)
+			value = b[:n]
+		}
+		out[num] = append(out[num], value)
+		b = b[n:]
+	}
+	return out
+}
+
+func TestJaegerExporterSendsOneRPCPerProcess(t *testing.T) {
+	collector := &fakeCollector{}
+	server := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}), grpc.UnknownServiceHandler(collector.handle))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	exporter := NewJaegerExporter("jaeger", JaegerConfig{Endpoint: listener.Addr().String()})
+
+	frontend := &model.Process{ServiceName: "frontend"}
+	backend := &model.Process{ServiceName: "backend"}
+	start := time.Unix(1700000000, 500)
+	in := make(chan []*model.Span, 1)
+	in <- []*model.Span{
+		{TraceID: model.TraceID{Low: 1}, SpanID: 1, OperationName: "GET /", StartTime: start, Duration: time.Second, Process: frontend},
+		{TraceID: model.TraceID{Low: 1}, SpanID: 2, ParentSpanID: 1, OperationName: "query", Process: backend,
+			Tags: []model.KeyValue{{Key: "rows", VType: model.Int64Type, VInt64: 3}}},
+		{TraceID: model.TraceID{Low: 1}, SpanID: 3, ParentSpanID: 1, OperationName: "render", Process: frontend},
+	}
+	close(in)
+
+	require.NoError(t, exporter.Export(context.Background(), in))
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	require.Len(t, collector.requests, 2)
+	assert.Equal(t, []string{postSpansMethod, postSpansMethod}, collector.methods)
+
+	// First RPC: frontend batch with spans 1 and 3
+	batch := fields(t, fields(t, collector.requests[0])[1][0])
+	require.Len(t, batch[1], 2)
+	process := fields(t, batch[2][0])
+	assert.Equal(t, "frontend", string(process[1][0]))
+
+	span := fields(t, batch[1][0])
+	assert.Equal(t, model.TraceID{Low: 1}.Bytes(), span[1][0])
+	assert.Equal(t, model.SpanID(1).Bytes(), span[2][0])
+	assert.Equal(t, "GET /", string(span[3][0]))
+	timestamp := fields(t, span[6][0])
+	seconds, _ := protowire.ConsumeVarint(timestamp[1][0])
+	nanos, _ := protowire.ConsumeVarint(timestamp[2][0])
+	assert.Equal(t, uint64(1700000000), seconds)
+	assert.Equal(t, uint64(500), nanos)
+
+	// Second RPC: backend span, parent encoded as a CHILD_OF reference
+	batch = fields(t, fields(t, collector.requests[1])[1][0])
+	require.Len(t, batch[1], 1)
+	span = fields(t, batch[1][0])
+	ref := fields(t, span[4][0])
+	assert.Equal(t, model.SpanID(1).Bytes(), ref[2][0])
+	assert.Empty(t, ref[3]) // CHILD_OF is the default
+	tag := fields(t, span[8][0])
+	assert.Equal(t, "rows", string(tag[1][0]))
+	vType, _ := protowire.ConsumeVarint(tag[2][0])
+	assert.Equal(t, uint64(2), vType)
+}
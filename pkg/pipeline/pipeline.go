@@ -23,19 +23,61 @@ type Processor[T any] interface {
 	Name() string
 }
 
+// Connector converts a stream of one type into another,
+// e.g. spans into assembled traces.
+type Connector[In, Out any] interface {
+	Process(ctx context.Context, in <-chan In) <-chan Out
+	Name() string
+}
+
 // Exporter sends telemetry data to a backend.
+// Exporters must not modify the data they receive.
 type Exporter[T any] interface {
 	Export(ctx context.Context, in <-chan T) error
 	Name() string
 }
 
+// Mutator is implemented by processors to declare whether they modify
+// the data they receive in place. Processors that don't implement it are
+// assumed to.
+type Mutator interface {
+	MutatesData() bool
+}
+
+// mutates reports whether any of the processors modifies its input
+func mutates[T any](processors []Processor[T]) bool {
+	for _, proc := range processors {
+		if m, ok := proc.(Mutator); !ok || m.MutatesData() {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneFunc returns a deep copy function for T: its Clone method, or
+// model.CloneSpans for batches. It returns nil for other types.
+func cloneFunc[T any]() func(T) T {
+	var zero T
+	switch any(zero).(type) {
+	case []*model.Span:
+		return func(item T) T {
+			return any(model.CloneSpans(any(item).([]*model.Span))).(T)
+		}
+	case interface{ Clone() T }:
+		return func(item T) T {
+			return any(item).(interface{ Clone() T }).Clone()
+		}
+	}
+	return nil
+}
+
 // Pipeline orchestrates data flow from receivers through processors to exporters.
 // Channel-based architecture (idiomatic Go) vs callback-based (OTel Collector).
 type Pipeline[T any] struct {
 	name       string
 	receiver   Receiver[T]
 	processors []Processor[T]
-	exporters  []Exporter[T]
+	branches   []branch[T]
 	errChan    chan error
 	wg         sync.WaitGroup
 }
@@ -46,7 +88,6 @@ func NewPipeline[T any](name string, receiver Receiver[T]) *Pipeline[T] {
 		name:       name,
 		receiver:   receiver,
 		processors: make([]Processor[T], 0),
-		exporters:  make([]Exporter[T], 0),
 		errChan:    make(chan error, 10),
 	}
 }
@@ -56,9 +97,16 @@ func (p *Pipeline[T]) AddProcessor(proc Processor[T]) {
 	p.processors = append(p.processors, proc)
 }
 
-// AddExporter adds an exporter to the pipeline
-func (p *Pipeline[T]) AddExporter(exp Exporter[T]) {
-	p.exporters = append(p.exporters, exp)
+// branch is an exporter with the processors applied only to its data
+type branch[T any] struct {
+	processors []Processor[T]
+	exporter   Exporter[T]
+}
+
+// AddExporter adds an exporter to the pipeline. Every exporter receives
+// all data; processors given here apply to this exporter's copy only.
+func (p *Pipeline[T]) AddExporter(exp Exporter[T], processors ...Processor[T]) {
+	p.branches = append(p.branches, branch[T]{processors: processors, exporter: exp})
 }
 
 // Run starts the pipeline and blocks until context is cancelled
@@ -75,14 +123,23 @@ func (p *Pipeline[T]) Run(ctx context.Context) error {
 	}
 
 	// Fan-out to exporters
-	for _, exp := range p.exporters {
+	outs, err := p.fanOut(ctx, data)
+	if err != nil {
+		_ = p.receiver.Stop(ctx)
+		return err
+	}
+	for i, b := range p.branches {
+		branchData := outs[i]
+		for _, proc := range b.processors {
+			branchData = proc.Process(ctx, branchData)
+		}
 		p.wg.Add(1)
-		go func(exporter Exporter[T]) {
+		go func(exporter Exporter[T], in <-chan T) {
 			defer p.wg.Done()
-			if err := exporter.Export(ctx, data); err != nil {
+			if err := exporter.Export(ctx, in); err != nil {
 				p.errChan <- fmt.Errorf("exporter %s failed: %w", exporter.Name(), err)
 			}
-		}(exp)
+		}(b.exporter, branchData)
 	}
 
 	// Wait for context cancellation or error
@@ -98,6 +155,113 @@ func (p *Pipeline[T]) Run(ctx context.Context) error {
 	}
 }
 
+// fanOut broadcasts data to one channel per branch. Copies are made on
+// write: branches that don't mutate share each item, branches with
+// mutating processors get a deep copy. When every branch mutates, the
+// last one gets the original.
+func (p *Pipeline[T]) fanOut(ctx context.Context, in <-chan T) ([]<-chan T, error) {
+	if len(p.branches) <= 1 {
+		return []<-chan T{in}, nil
+	}
+
+	clone := make([]bool, len(p.branches))
+	shared, copies := false, 0
+	for i, b := range p.branches {
+		clone[i] = mutates(b.processors)
+		shared = shared || !clone[i]
+	}
+	if !shared {
+		clone[len(clone)-1] = false
+	}
+	for _, c := range clone {
+		if c {
+			copies++
+		}
+	}
+
+	cloneItem := cloneFunc[T]()
+	if cloneItem == nil && copies > 0 {
+		return nil, fmt.Errorf("pipeline %s: cannot copy %T for mutating exporter branches", p.name, *new(T))
+	}
+
+	chans := make([]chan T, len(p.branches))
+	outs := make([]<-chan T, len(p.branches))
+	for i := range chans {
+		chans[i] = make(chan T)
+		outs[i] = chans[i]
+	}
+
+	go func() {
+		defer func() {
+			for _, ch := range chans {
+				close(ch)
+			}
+		}()
+		items := make([]T, len(chans))
+		for item := range in {
+			// Copy before sending, as a branch may modify the
+			// original as soon as it has it
+			for i := range items {
+				items[i] = item
+				if clone[i] {
+					items[i] = cloneItem(item)
+				}
+			}
+			for i, ch := range chans {
+				select {
+				case ch <- items[i]:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return outs, nil
+}
+
+// connectedReceiver presents a receiver, its processors and a connector
+// as a single receiver of the connector's output type
+type connectedReceiver[In, Out any] struct {
+	receiver   Receiver[In]
+	processors []Processor[In]
+	connector  Connector[In, Out]
+}
+
+// Connect chains a receiver through processors into a connector and
+// returns the result as a Receiver[Out], so it can feed a Pipeline[Out]:
+//
+//	traces := pipeline.Connect(otlp, spanProcessors, groupByTrace)
+//	p := pipeline.NewTracePipeline("traces", traces)
+func Connect[In, Out any](receiver Receiver[In], processors []Processor[In], connector Connector[In, Out]) Receiver[Out] {
+	return &connectedReceiver[In, Out]{
+		receiver:   receiver,
+		processors: processors,
+		connector:  connector,
+	}
+}
+
+// Start starts the upstream receiver and chains its output
+func (c *connectedReceiver[In, Out]) Start(ctx context.Context) (<-chan Out, error) {
+	data, err := c.receiver.Start(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start receiver %s: %w", c.receiver.Name(), err)
+	}
+	for _, proc := range c.processors {
+		data = proc.Process(ctx, data)
+	}
+	return c.connector.Process(ctx, data), nil
+}
+
+// Stop stops the upstream receiver
+func (c *connectedReceiver[In, Out]) Stop(ctx context.Context) error {
+	return c.receiver.Stop(ctx)
+}
+
+// Name returns the receiver and connector names
+func (c *connectedReceiver[In, Out]) Name() string {
+	return c.receiver.Name() + "/" + c.connector.Name()
+}
+
 // SpanPipeline is a pipeline for spans (convenience type)
 type SpanPipeline = Pipeline[*model.Span]
 
@@ -105,3 +269,20 @@ type SpanPipeline = Pipeline[*model.Span]
 func NewSpanPipeline(name string, receiver Receiver[*model.Span]) *SpanPipeline {
 	return NewPipeline[*model.Span](name, receiver)
 }
+
+// BatchPipeline is a pipeline for span batches, e.g. fed by
+// Connect(receiver, processors, batchProcessor) (convenience type)
+type BatchPipeline = Pipeline[[]*model.Span]
+
+// NewBatchPipeline creates a new batch pipeline
+func NewBatchPipeline(name string, receiver Receiver[[]*model.Span]) *BatchPipeline {
+	return NewPipeline[[]*model.Span](name, receiver)
+}
+
+// TracePipeline is a pipeline for assembled traces (convenience type)
+type TracePipeline = Pipeline[*model.Trace]
+
+// NewTracePipeline creates a new trace pipeline
+func NewTracePipeline(name string, receiver Receiver[*model.Trace]) *TracePipeline {
+	return NewPipeline[*model.Trace](name, receiver)
+}
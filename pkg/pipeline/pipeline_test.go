@@ -0,0 +1,253 @@
+package pipeline
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+)
+
+// chanReceiver emits what the test sends on ch
+type chanReceiver[T any] struct {
+	ch chan T
+}
+
+func (r *chanReceiver[T]) Start(ctx context.Context) (<-chan T, error) { return r.ch, nil }
+func (r *chanReceiver[T]) Stop(ctx context.Context) error              { return nil }
+func (r *chanReceiver[T]) Name() string                                { return "test" }
+
+// collectExporter records everything it receives, reading span tags like
+// a real exporter would
+type collectExporter[T any] struct {
+	name  string
+	read  func(T)
+	items []T
+	done  chan struct{}
+}
+
+func newCollectExporter[T any](name string, read func(T)) *collectExporter[T] {
+	return &collectExporter[T]{name: name, read: read, done: make(chan struct{})}
+}
+
+func (e *collectExporter[T]) Export(ctx context.Context, in <-chan T) error {
+	defer close(e.done)
+	for item := range in {
+		e.read(item)
+		e.items = append(e.items, item)
+	}
+	return nil
+}
+
+func (e *collectExporter[T]) Name() string { return e.name }
+
+func readSpan(span *model.Span) {
+	for _, tag := range span.Tags {
+		_ = tag.VStr
+	}
+}
+
+// tagProcessor sets a tag on every span, modifying it in place
+type tagProcessor struct {
+	value string
+}
+
+func (p *tagProcessor) Process(ctx context.Context, in <-chan *model.Span) <-chan *model.Span {
+	out := make(chan *model.Span)
+	go func() {
+		defer close(out)
+		for span := range in {
+			span.SetTag(model.String("branch", p.value))
+			out <- span
+		}
+	}()
+	return out
+}
+
+func (p *tagProcessor) Name() string      { return "tag/" + p.value }
+func (p *tagProcessor) MutatesData() bool { return true }
+
+// undeclaredProcessor modifies spans without implementing Mutator
+type undeclaredProcessor struct {
+	tags tagProcessor
+}
+
+func (p *undeclaredProcessor) Process(ctx context.Context, in <-chan *model.Span) <-chan *model.Span {
+	return p.tags.Process(ctx, in)
+}
+
+func (p *undeclaredProcessor) Name() string { return "undeclared" }
+
+// runPipeline sends spans through the pipeline and waits for every
+// exporter to finish
+func runPipeline[T any](t *testing.T, p *Pipeline[T], receiver *chanReceiver[T], items []T, done ...chan struct{}) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- p.Run(ctx) }()
+
+	for _, item := range items {
+		receiver.ch <- item
+	}
+	close(receiver.ch)
+	for _, d := range done {
+		select {
+		case <-d:
+		case <-time.After(5 * time.Second):
+			t.Fatal("exporter did not finish")
+		}
+	}
+	cancel()
+	assert.ErrorIs(t, <-result, context.Canceled)
+}
+
+func testSpans(n int) []*model.Span {
+	spans := make([]*model.Span, n)
+	for i := range spans {
+		spans[i] = &model.Span{
+			TraceID:       model.TraceID{Low: 1},
+			SpanID:        model.SpanID(i + 1),
+			OperationName: "op" + strconv.Itoa(i),
+			Tags:          []model.KeyValue{model.String("k", "v")},
+		}
+	}
+	return spans
+}
+
+func TestPipelineBroadcastsToEveryExporter(t *testing.T) {
+	receiver := &chanReceiver[*model.Span]{ch: make(chan *model.Span)}
+	p := NewSpanPipeline("test", receiver)
+	a := newCollectExporter("a", readSpan)
+	b := newCollectExporter("b", readSpan)
+	p.AddExporter(a)
+	p.AddExporter(b)
+
+	spans := testSpans(50)
+	runPipeline(t, p, receiver, spans, a.done, b.done)
+
+	// Neither branch mutates, so both see the original spans
+	require.Len(t, a.items, 50)
+	require.Len(t, b.items, 50)
+	for i := range spans {
+		assert.Same(t, spans[i], a.items[i])
+		assert.Same(t, spans[i], b.items[i])
+	}
+}
+
+func TestPipelineCopiesForMutatingBranches(t *testing.T) {
+	receiver := &chanReceiver[*model.Span]{ch: make(chan *model.Span)}
+	p := NewSpanPipeline("test", receiver)
+	plain := newCollectExporter("plain", readSpan)
+	tagged := newCollectExporter("tagged", readSpan)
+	p.AddExporter(plain)
+	p.AddExporter(tagged, &tagProcessor{value: "a"})
+
+	spans := testSpans(200)
+	runPipeline(t, p, receiver, spans, plain.done, tagged.done)
+
+	require.Len(t, plain.items, 200)
+	require.Len(t, tagged.items, 200)
+	for i := range spans {
+		assert.Same(t, spans[i], plain.items[i])
+		assert.Len(t, plain.items[i].Tags, 1, "the shared span is not modified")
+
+		assert.NotSame(t, spans[i], tagged.items[i])
+		tag, ok := tagged.items[i].Tag("branch")
+		require.True(t, ok)
+		assert.Equal(t, "a", tag.VStr)
+	}
+}
+
+func TestPipelineLastMutatingBranchGetsOriginal(t *testing.T) {
+	receiver := &chanReceiver[*model.Span]{ch: make(chan *model.Span)}
+	p := NewSpanPipeline("test", receiver)
+	a := newCollectExporter("a", readSpan)
+	b := newCollectExporter("b", readSpan)
+	c := newCollectExporter("c", readSpan)
+	p.AddExporter(a, &tagProcessor{value: "a"})
+	p.AddExporter(b, &undeclaredProcessor{tagProcessor{value: "b"}})
+	p.AddExporter(c, &tagProcessor{value: "c"})
+
+	spans := testSpans(100)
+	runPipeline(t, p, receiver, spans, a.done, b.done, c.done)
+
+	for i := range spans {
+		assert.NotSame(t, spans[i], a.items[i])
+		assert.NotSame(t, spans[i], b.items[i], "processors without Mutator are assumed to mutate")
+		assert.Same(t, spans[i], c.items[i])
+
+		for _, exp := range []*collectExporter[*model.Span]{a, b, c} {
+			tag, _ := exp.items[i].Tag("branch")
+			assert.Equal(t, exp.name, tag.VStr)
+		}
+	}
+}
+
+func TestBatchPipelineCopiesBatches(t *testing.T) {
+	receiver := &chanReceiver[[]*model.Span]{ch: make(chan []*model.Span)}
+	p := NewBatchPipeline("test", receiver)
+	read := func(batch []*model.Span) {
+		for _, span := range batch {
+			readSpan(span)
+		}
+	}
+	a := newCollectExporter("a", read)
+	b := newCollectExporter("b", read)
+	p.AddExporter(a)
+	p.AddExporter(b, &batchTagProcessor{})
+
+	batch := testSpans(10)
+	process := &model.Process{ServiceName: "cart"}
+	for _, span := range batch {
+		span.Process = process
+	}
+	runPipeline(t, p, receiver, [][]*model.Span{batch}, a.done, b.done)
+
+	require.Len(t, b.items, 1)
+	assert.Len(t, batch[0].Tags, 1)
+	assert.Len(t, b.items[0][0].Tags, 2)
+	// The copied spans share one copy of their process
+	assert.NotSame(t, process, b.items[0][0].Process)
+	for _, span := range b.items[0] {
+		assert.Same(t, b.items[0][0].Process, span.Process)
+	}
+}
+
+// batchTagProcessor tags every span of each batch in place
+type batchTagProcessor struct{}
+
+func (p *batchTagProcessor) Process(ctx context.Context, in <-chan []*model.Span) <-chan []*model.Span {
+	out := make(chan []*model.Span)
+	go func() {
+		defer close(out)
+		for batch := range in {
+			for _, span := range batch {
+				span.SetTag(model.String("batched", "true"))
+			}
+			out <- batch
+		}
+	}()
+	return out
+}
+
+func (p *batchTagProcessor) Name() string { return "batchtag" }
+
+func TestPipelineRejectsUncopyableMutatingBranches(t *testing.T) {
+	receiver := &chanReceiver[string]{ch: make(chan string)}
+	p := NewPipeline[string]("test", receiver)
+	p.AddExporter(newCollectExporter("a", func(string) {}))
+	p.AddExporter(newCollectExporter("b", func(string) {}), &stringProcessor{})
+
+	err := p.Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot copy")
+}
+
+// stringProcessor passes strings through without declaring Mutator
+type stringProcessor struct{}
+
+func (p *stringProcessor) Process(ctx context.Context, in <-chan string) <-chan string { return in }
+func (p *stringProcessor) Name() string                                                { return "string" }
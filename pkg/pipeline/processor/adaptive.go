@@ -0,0 +1,194 @@
+package processor
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+// AdaptiveConfig configures the adaptive sampling controller
+type AdaptiveConfig struct {
+	TargetSpansPerSecond float64       // Desired probabilistic output throughput (0 = hold base rate)
+	TargetErrorShare     float64       // Input error share above which the rate is boosted (0 = disabled)
+	Window               time.Duration // Feedback interval
+	Smoothing            float64       // EWMA weight of the latest window (0.0 - 1.0]
+	MinSampleRate        float64       // Lower bound for the adaptive rate
+	MaxSampleRate        float64       // Upper bound for the adaptive rate
+}
+
+// DefaultAdaptiveConfig returns sensible controller defaults
+func DefaultAdaptiveConfig() AdaptiveConfig {
+	return AdaptiveConfig{
+		TargetSpansPerSecond: 0,                // Hold the base rate
+		TargetErrorShare:     0.01,             // Boost above 1% errors
+		Window:               10 * time.Second, // Adjust every 10s
+		Smoothing:            0.3,              // Favor history over the latest window
+		MinSampleRate:        0.001,
+		MaxSampleRate:        1.0,
+	}
+}
+
+// AdaptiveController computes the sampling rate from smoothed feedback.
+//
+// Every window it measures the input throughput and error share, folds
+// them into exponentially weighted moving averages and derives a rate:
+//
+//	rate = TargetSpansPerSecond / smoothedInputRate   (or the base rate)
+//	rate *= smoothedErrorShare / TargetErrorShare     (when above target)
+//
+// clamped to [MinSampleRate, MaxSampleRate]. All state is held in
+// atomics; the goroutine that crosses a window boundary performs the
+// adjustment while every other caller only increments counters.
+type AdaptiveController struct {
+	name     string
+	baseRate float64
+	config   AdaptiveConfig
+	metrics  *observability.Metrics
+	now      func() time.Time
+
+	// Current window counters
+	total  atomic.Uint64
+	errors atomic.Uint64
+
+	// Window bookkeeping (UnixNano)
+	windowStart  atomic.Int64
+	lastAdjusted atomic.Int64
+	warm         atomic.Bool
+
+	// Float64 state stored as bits
+	rate               atomic.Uint64
+	smoothedInputRate  atomic.Uint64
+	smoothedErrorShare atomic.Uint64
+}
+
+// NewAdaptiveController creates a controller starting at baseRate
+func NewAdaptiveController(name string, baseRate float64, config AdaptiveConfig, metrics *observability.Metrics) *AdaptiveController {
+	if config.Window <= 0 {
+		config.Window = DefaultAdaptiveConfig().Window
+	}
+	if config.Smoothing <= 0 || config.Smoothing > 1 {
+		config.Smoothing = DefaultAdaptiveConfig().Smoothing
+	}
+	if config.MaxSampleRate <= 0 || config.MaxSampleRate > 1 {
+		config.MaxSampleRate = 1.0
+	}
+	if config.MinSampleRate > config.MaxSampleRate {
+		config.MinSampleRate = config.MaxSampleRate
+	}
+
+	c := &AdaptiveController{
+		name:     name,
+		baseRate: baseRate,
+		config:   config,
+		metrics:  metrics,
+		now:      time.Now,
+	}
+	c.windowStart.Store(c.now().UnixNano())
+	c.rate.Store(math.Float64bits(baseRate))
+	return c
+}
+
+// Observe records a span seen by the sampler and adjusts the rate
+// when the current window has elapsed
+func (c *AdaptiveController) Observe(isError bool) {
+	c.total.Add(1)
+	if isError {
+		c.errors.Add(1)
+	}
+	c.maybeAdjust(c.now())
+}
+
+// Rate returns the current sampling rate
+func (c *AdaptiveController) Rate() float64 {
+	return math.Float64frombits(c.rate.Load())
+}
+
+// maybeAdjust runs an adjustment if the window elapsed and this caller
+// won the race to close it
+func (c *AdaptiveController) maybeAdjust(now time.Time) {
+	start := c.windowStart.Load()
+	elapsed := now.UnixNano() - start
+	if elapsed < int64(c.config.Window) {
+		return
+	}
+	if !c.windowStart.CompareAndSwap(start, now.UnixNano()) {
+		return
+	}
+	c.adjust(time.Duration(elapsed), now)
+}
+
+// adjust folds the closed window into the smoothed state and
+// recomputes the rate
+func (c *AdaptiveController) adjust(elapsed time.Duration, now time.Time) {
+	total := c.total.Swap(0)
+	errs := c.errors.Swap(0)
+
+	inputRate := float64(total) / elapsed.Seconds()
+	errorShare := 0.0
+	if total > 0 {
+		errorShare = float64(errs) / float64(total)
+	}
+
+	if c.warm.Swap(true) {
+		alpha := c.config.Smoothing
+		inputRate = alpha*inputRate + (1-alpha)*math.Float64frombits(c.smoothedInputRate.Load())
+		errorShare = alpha*errorShare + (1-alpha)*math.Float64frombits(c.smoothedErrorShare.Load())
+	}
+	c.smoothedInputRate.Store(math.Float64bits(inputRate))
+	c.smoothedErrorShare.Store(math.Float64bits(errorShare))
+
+	rate := c.baseRate
+	if c.config.TargetSpansPerSecond > 0 && inputRate > 0 {
+		rate = c.config.TargetSpansPerSecond / inputRate
+	}
+	if c.config.TargetErrorShare > 0 && errorShare > c.config.TargetErrorShare {
+		rate *= errorShare / c.config.TargetErrorShare
+	}
+	rate = min(c.config.MaxSampleRate, rate)
+	if rate < c.config.MinSampleRate {
+		rate = c.config.MinSampleRate
+	}
+
+	c.rate.Store(math.Float64bits(rate))
+	c.lastAdjusted.Store(now.UnixNano())
+	c.publish()
+}
+
+// publish exports controller state as gauges
+func (c *AdaptiveController) publish() {
+	if c.metrics == nil {
+		return
+	}
+	state := c.State()
+	prefix := "sampling." + c.name + "."
+	c.metrics.SetGauge(prefix+"rate", state.Rate)
+	c.metrics.SetGauge(prefix+"smoothed_input_rate", state.SmoothedInputRate)
+	c.metrics.SetGauge(prefix+"smoothed_error_share", state.SmoothedErrorShare)
+}
+
+// State returns a snapshot of the controller for graphing
+func (c *AdaptiveController) State() ControllerState {
+	state := ControllerState{
+		Rate:               c.Rate(),
+		SmoothedInputRate:  math.Float64frombits(c.smoothedInputRate.Load()),
+		SmoothedErrorShare: math.Float64frombits(c.smoothedErrorShare.Load()),
+		WindowTotal:        c.total.Load(),
+		WindowErrors:       c.errors.Load(),
+	}
+	if ts := c.lastAdjusted.Load(); ts != 0 {
+		state.LastAdjusted = time.Unix(0, ts)
+	}
+	return state
+}
+
+// ControllerState represents the adaptive controller state
+type ControllerState struct {
+	Rate               float64
+	SmoothedInputRate  float64 // Spans per second
+	SmoothedErrorShare float64 // 0.0 - 1.0
+	WindowTotal        uint64
+	WindowErrors       uint64
+	LastAdjusted       time.Time
+}
@@ -0,0 +1,130 @@
+package processor
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+// newTestController returns a controller driven by a manual clock
+func newTestController(baseRate float64, config AdaptiveConfig) (*AdaptiveController, *time.Time) {
+	now := time.Unix(1700000000, 0)
+	c := NewAdaptiveController("test", baseRate, config, observability.NewMetrics())
+	c.now = func() time.Time { return now }
+	c.windowStart.Store(now.UnixNano())
+	return c, &now
+}
+
+func TestAdaptiveControllerTargetsThroughput(t *testing.T) {
+	config := DefaultAdaptiveConfig()
+	config.TargetSpansPerSecond = 100
+	config.Smoothing = 1.0 // No smoothing
+	c, now := newTestController(0.1, config)
+
+	// 10k spans over a 10s window = 1000 spans/s
+	for i := 0; i < 10000; i++ {
+		c.Observe(false)
+	}
+	*now = now.Add(config.Window)
+	c.Observe(false)
+
+	assert.InDelta(t, 0.1, c.Rate(), 0.001)
+	assert.InDelta(t, 1000.0, c.State().SmoothedInputRate, 1)
+}
+
+func TestAdaptiveControllerBoostsOnErrors(t *testing.T) {
+	config := DefaultAdaptiveConfig()
+	config.TargetErrorShare = 0.01
+	config.Smoothing = 1.0
+	c, now := newTestController(0.1, config)
+
+	// 4% errors -> 4x the base rate
+	for i := 0; i < 1000; i++ {
+		c.Observe(i%25 == 0)
+	}
+	*now = now.Add(config.Window)
+	c.Observe(false)
+
+	assert.InDelta(t, 0.4, c.Rate(), 0.001)
+}
+
+func TestAdaptiveControllerClampsRate(t *testing.T) {
+	config := DefaultAdaptiveConfig()
+	config.TargetSpansPerSecond = 1
+	config.MinSampleRate = 0.05
+	config.MaxSampleRate = 0.5
+	config.Smoothing = 1.0
+	c, now := newTestController(0.1, config)
+
+	for i := 0; i < 100000; i++ {
+		c.Observe(false)
+	}
+	*now = now.Add(config.Window)
+	c.Observe(false)
+	assert.Equal(t, 0.05, c.Rate())
+
+	// Almost idle input pushes the rate to the ceiling
+	*now = now.Add(config.Window)
+	c.Observe(false)
+	assert.Equal(t, 0.5, c.Rate())
+}
+
+func TestAdaptiveControllerSmoothing(t *testing.T) {
+	config := DefaultAdaptiveConfig()
+	config.Smoothing = 0.5
+	c, now := newTestController(0.1, config)
+
+	// First window initializes the average
+	for i := 0; i < 1000; i++ {
+		c.Observe(false)
+	}
+	*now = now.Add(config.Window)
+	c.Observe(false)
+	assert.InDelta(t, 100.1, c.State().SmoothedInputRate, 0.001)
+
+	// Second window is idle apart from the closing span
+	*now = now.Add(config.Window)
+	c.Observe(false)
+	assert.InDelta(t, 50.1, c.State().SmoothedInputRate, 0.001)
+}
+
+func TestAdaptiveControllerPublishesGauges(t *testing.T) {
+	metrics := observability.NewMetrics()
+	config := DefaultAdaptiveConfig()
+	c := NewAdaptiveController("adaptive", 0.2, config, metrics)
+	now := time.Now().Add(config.Window)
+	c.now = func() time.Time { return now }
+
+	c.Observe(false)
+
+	gauges := metrics.Snapshot().Gauges
+	assert.Equal(t, 0.2, gauges["sampling.adaptive.rate"])
+	assert.Contains(t, gauges, "sampling.adaptive.smoothed_input_rate")
+	assert.Contains(t, gauges, "sampling.adaptive.smoothed_error_share")
+}
+
+func TestAdaptiveControllerConcurrentObserve(t *testing.T) {
+	config := DefaultAdaptiveConfig()
+	config.Window = time.Millisecond
+	c := NewAdaptiveController("test", 0.1, config, observability.NewMetrics())
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 10000; i++ {
+				c.Observe(i%(g+2) == 0)
+				_ = c.Rate()
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	rate := c.Rate()
+	assert.GreaterOrEqual(t, rate, config.MinSampleRate)
+	assert.LessOrEqual(t, rate, config.MaxSampleRate)
+}
@@ -2,21 +2,36 @@ package processor
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
 
+	"github.com/vjranagit/jaeger-toolkit/pkg/filter"
 	"github.com/vjranagit/jaeger-toolkit/pkg/model"
 )
 
 // AttributesProcessor modifies span attributes (tags)
 type AttributesProcessor struct {
 	name    string
-	actions []AttributeAction
+	actions []compiledAction
+	match   *filter.Matcher
 }
 
 // AttributeAction represents a single attribute modification
 type AttributeAction struct {
-	Key    string
-	Value  string
-	Action ActionType
+	Key           string          // Exact key; target key for insert/update/upsert
+	KeyRegex      string          // Match existing keys by regular expression
+	KeyGlob       string          // Match existing keys by glob (path.Match syntax)
+	Value         string          // Literal value, parsed according to Type
+	Type          model.ValueType // Type of Value (default: string)
+	FromAttribute string          // Take the value from another tag instead of Value
+	Pattern       string          // Regex with named groups (extract)
+	ConvertedType model.ValueType // Target type (convert)
+	Action        ActionType
 }
 
 // ActionType specifies how to modify an attribute
@@ -31,19 +46,122 @@ const (
 	Upsert ActionType = "upsert"
 	// Delete removes attribute
 	Delete ActionType = "delete"
+	// Hash replaces the value with its hex-encoded SHA-256
+	Hash ActionType = "hash"
+	// Extract copies regex named groups into new attributes
+	Extract ActionType = "extract"
+	// Convert changes the value type (string, int64, float64, bool)
+	Convert ActionType = "convert"
 )
 
 // AttributesConfig configures the attributes processor
 type AttributesConfig struct {
 	Actions []AttributeAction
+	Match   *filter.Matcher // Optional include/exclude; other spans pass unchanged
+}
+
+// compiledAction is an AttributeAction with its patterns and value
+// resolved once at construction
+type compiledAction struct {
+	AttributeAction
+	keyRegex *regexp.Regexp
+	pattern  *regexp.Regexp
+	value    model.KeyValue
 }
 
 // NewAttributesProcessor creates a new attributes processor
-func NewAttributesProcessor(name string, config AttributesConfig) *AttributesProcessor {
+func NewAttributesProcessor(name string, config AttributesConfig) (*AttributesProcessor, error) {
+	actions := make([]compiledAction, 0, len(config.Actions))
+	for i, action := range config.Actions {
+		compiled, err := compileAction(action)
+		if err != nil {
+			return nil, fmt.Errorf("action %d (%s): %w", i, action.Action, err)
+		}
+		actions = append(actions, compiled)
+	}
+
 	return &AttributesProcessor{
 		name:    name,
-		actions: config.Actions,
+		actions: actions,
+		match:   config.Match,
+	}, nil
+}
+
+// compileAction validates an action and precompiles its patterns
+func compileAction(action AttributeAction) (compiledAction, error) {
+	c := compiledAction{AttributeAction: action}
+
+	if action.KeyRegex != "" {
+		re, err := regexp.Compile(action.KeyRegex)
+		if err != nil {
+			return c, fmt.Errorf("invalid key_regex: %w", err)
+		}
+		c.keyRegex = re
+	}
+	if action.KeyGlob != "" {
+		if _, err := path.Match(action.KeyGlob, ""); err != nil {
+			return c, fmt.Errorf("invalid key_glob %q: %w", action.KeyGlob, err)
+		}
 	}
+	hasMatcher := action.Key != "" || action.KeyRegex != "" || action.KeyGlob != ""
+
+	switch action.Action {
+	case Insert, Update, Upsert:
+		if action.Key == "" {
+			return c, fmt.Errorf("key is required")
+		}
+		if action.FromAttribute == "" {
+			valueType := action.Type
+			if valueType == "" {
+				valueType = model.StringType
+			}
+			value, err := parseValue(action.Key, action.Value, valueType)
+			if err != nil {
+				return c, err
+			}
+			c.value = value
+		}
+
+	case Delete, Hash:
+		if !hasMatcher {
+			return c, fmt.Errorf("key, key_regex or key_glob is required")
+		}
+
+	case Extract:
+		if !hasMatcher {
+			return c, fmt.Errorf("key, key_regex or key_glob is required")
+		}
+		re, err := regexp.Compile(action.Pattern)
+		if err != nil {
+			return c, fmt.Errorf("invalid pattern: %w", err)
+		}
+		named := false
+		for _, name := range re.SubexpNames() {
+			if name != "" {
+				named = true
+				break
+			}
+		}
+		if !named {
+			return c, fmt.Errorf("pattern %q has no named groups", action.Pattern)
+		}
+		c.pattern = re
+
+	case Convert:
+		if !hasMatcher {
+			return c, fmt.Errorf("key, key_regex or key_glob is required")
+		}
+		switch action.ConvertedType {
+		case model.StringType, model.Int64Type, model.Float64Type, model.BoolType:
+		default:
+			return c, fmt.Errorf("unsupported converted_type %q", action.ConvertedType)
+		}
+
+	default:
+		return c, fmt.Errorf("unknown action")
+	}
+
+	return c, nil
 }
 
 // Process applies attribute modifications to spans
@@ -60,9 +178,15 @@ func (p *AttributesProcessor) Process(ctx context.Context, in <-chan *model.Span
 					return
 				}
 
-				// Apply all actions to this span
-				for _, action := range p.actions {
-					p.applyAction(span, action)
+				// Apply all actions to matching spans
+				if p.match.Match(span) {
+					var tags spanTags = span
+					if len(span.Tags) >= tagIndexThreshold && len(p.actions) > 1 {
+						tags = span.IndexTags()
+					}
+					for i := range p.actions {
+						p.applyAction(span, tags, &p.actions[i])
+					}
 				}
 
 				select {
@@ -80,59 +204,186 @@ func (p *AttributesProcessor) Process(ctx context.Context, in <-chan *model.Span
 	return out
 }
 
+// tagIndexThreshold is the tag count from which a span's tags are
+// indexed for the actions applied to it
+const tagIndexThreshold = 16
+
+// spanTags looks up and sets tags of a span, directly or through a
+// model.TagIndex
+type spanTags interface {
+	Tag(key string) (model.KeyValue, bool)
+	SetTag(kv model.KeyValue)
+}
+
 // applyAction applies a single action to a span
-func (p *AttributesProcessor) applyAction(span *model.Span, action AttributeAction) {
-	// Find existing tag
-	existingIdx := -1
-	for i, tag := range span.Tags {
-		if tag.Key == action.Key {
-			existingIdx = i
-			break
+func (p *AttributesProcessor) applyAction(span *model.Span, tags spanTags, action *compiledAction) {
+	switch action.Action {
+	case Insert, Update, Upsert:
+		p.applySet(tags, action)
+
+	case Delete:
+		kept := span.Tags[:0]
+		for _, tag := range span.Tags {
+			if !action.matches(tag.Key) {
+				kept = append(kept, tag)
+			}
+		}
+		span.Tags = kept
+		if index, ok := tags.(*model.TagIndex); ok {
+			index.Reindex() // Positions moved
+		}
+
+	case Hash:
+		for i, tag := range span.Tags {
+			if action.matches(tag.Key) {
+				span.Tags[i] = hashValue(tag)
+			}
 		}
+
+	case Extract:
+		// Collect first: upserting while ranging could match new keys
+		extracted := make([]model.KeyValue, 0)
+		for _, tag := range span.Tags {
+			if !action.matches(tag.Key) || tag.VType != model.StringType {
+				continue
+			}
+			match := action.pattern.FindStringSubmatch(tag.VStr)
+			if match == nil {
+				continue
+			}
+			for g, name := range action.pattern.SubexpNames() {
+				if name != "" {
+					extracted = append(extracted, model.KeyValue{Key: name, VType: model.StringType, VStr: match[g]})
+				}
+			}
+		}
+		for _, kv := range extracted {
+			tags.SetTag(kv)
+		}
+
+	case Convert:
+		for i, tag := range span.Tags {
+			if !action.matches(tag.Key) {
+				continue
+			}
+			if converted, err := parseValue(tag.Key, tag.AsString(), action.ConvertedType); err == nil {
+				span.Tags[i] = converted
+			}
+		}
+	}
+}
+
+// applySet handles insert, update and upsert
+func (p *AttributesProcessor) applySet(tags spanTags, action *compiledAction) {
+	value := action.value
+	if action.FromAttribute != "" {
+		from, ok := tags.Tag(action.FromAttribute)
+		if !ok {
+			return
+		}
+		value = from
+		value.Key = action.Key
 	}
 
+	_, exists := tags.Tag(action.Key)
+
 	switch action.Action {
 	case Insert:
-		if existingIdx == -1 {
-			span.Tags = append(span.Tags, model.KeyValue{
-				Key:   action.Key,
-				VType: model.StringType,
-				VStr:  action.Value,
-			})
+		if !exists {
+			tags.SetTag(value)
 		}
 
 	case Update:
-		if existingIdx >= 0 {
-			span.Tags[existingIdx] = model.KeyValue{
-				Key:   action.Key,
-				VType: model.StringType,
-				VStr:  action.Value,
-			}
+		if exists {
+			tags.SetTag(value)
 		}
 
 	case Upsert:
-		if existingIdx >= 0 {
-			span.Tags[existingIdx] = model.KeyValue{
-				Key:   action.Key,
-				VType: model.StringType,
-				VStr:  action.Value,
-			}
-		} else {
-			span.Tags = append(span.Tags, model.KeyValue{
-				Key:   action.Key,
-				VType: model.StringType,
-				VStr:  action.Value,
-			})
+		tags.SetTag(value)
+	}
+}
+
+// matches reports whether key is selected by the action
+func (a *compiledAction) matches(key string) bool {
+	if a.Key != "" && key == a.Key {
+		return true
+	}
+	if a.keyRegex != nil && a.keyRegex.MatchString(key) {
+		return true
+	}
+	if a.KeyGlob != "" {
+		if ok, _ := path.Match(a.KeyGlob, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// findTag returns the index of key in tags, or -1
+func findTag(tags []model.KeyValue, key string) int {
+	for i, tag := range tags {
+		if tag.Key == key {
+			return i
 		}
+	}
+	return -1
+}
 
-	case Delete:
-		if existingIdx >= 0 {
-			span.Tags = append(span.Tags[:existingIdx], span.Tags[existingIdx+1:]...)
+// hashValue replaces a tag value with its hex-encoded SHA-256
+func hashValue(tag model.KeyValue) model.KeyValue {
+	var sum [32]byte
+	if tag.VType == model.BinaryType {
+		sum = sha256.Sum256(tag.VBinary)
+	} else {
+		sum = sha256.Sum256([]byte(tag.AsString()))
+	}
+	return model.KeyValue{Key: tag.Key, VType: model.StringType, VStr: hex.EncodeToString(sum[:])}
+}
+
+// parseValue builds a typed KeyValue from its string form
+func parseValue(key, value string, valueType model.ValueType) (model.KeyValue, error) {
+	kv := model.KeyValue{Key: key, VType: valueType}
+
+	switch valueType {
+	case model.StringType:
+		kv.VStr = value
+	case model.Int64Type:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return kv, fmt.Errorf("invalid int64 value %q for %s", value, key)
+		}
+		kv.VInt64 = v
+	case model.Float64Type:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return kv, fmt.Errorf("invalid float64 value %q for %s", value, key)
 		}
+		kv.VFloat64 = v
+	case model.BoolType:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return kv, fmt.Errorf("invalid bool value %q for %s", value, key)
+		}
+		kv.VBool = v
+	case model.BinaryType:
+		v, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return kv, fmt.Errorf("invalid base64 binary value for %s", key)
+		}
+		kv.VBinary = v
+	default:
+		return kv, fmt.Errorf("unsupported value type %q for %s", valueType, key)
 	}
+
+	return kv, nil
 }
 
 // Name returns the processor name
 func (p *AttributesProcessor) Name() string {
 	return p.name
 }
+
+// MutatesData reports that the processor modifies spans in place
+func (p *AttributesProcessor) MutatesData() bool {
+	return true
+}
@@ -0,0 +1,209 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+)
+
+func applyActions(t *testing.T, span *model.Span, actions ...AttributeAction) {
+	t.Helper()
+	processor, err := NewAttributesProcessor("test-attributes", AttributesConfig{Actions: actions})
+	require.NoError(t, err)
+	for i := range processor.actions {
+		processor.applyAction(span, span, &processor.actions[i])
+	}
+}
+
+func tagByKey(span *model.Span, key string) (model.KeyValue, bool) {
+	for _, tag := range span.Tags {
+		if tag.Key == key {
+			return tag, true
+		}
+	}
+	return model.KeyValue{}, false
+}
+
+func TestAttributesProcessorTypedValues(t *testing.T) {
+	span := &model.Span{}
+	applyActions(t, span,
+		AttributeAction{Key: "retries", Value: "3", Type: model.Int64Type, Action: Insert},
+		AttributeAction{Key: "sampled", Value: "true", Type: model.BoolType, Action: Upsert},
+		AttributeAction{Key: "env", Value: "prod", Action: Upsert},
+	)
+
+	retries, _ := tagByKey(span, "retries")
+	assert.Equal(t, model.KeyValue{Key: "retries", VType: model.Int64Type, VInt64: 3}, retries)
+	sampled, _ := tagByKey(span, "sampled")
+	assert.Equal(t, model.KeyValue{Key: "sampled", VType: model.BoolType, VBool: true}, sampled)
+	env, _ := tagByKey(span, "env")
+	assert.Equal(t, model.KeyValue{Key: "env", VType: model.StringType, VStr: "prod"}, env)
+}
+
+func TestAttributesProcessorFromAttribute(t *testing.T) {
+	span := &model.Span{Tags: []model.KeyValue{
+		{Key: "http.status_code", VType: model.Int64Type, VInt64: 404},
+	}}
+	applyActions(t, span,
+		AttributeAction{Key: "status", FromAttribute: "http.status_code", Action: Insert},
+		AttributeAction{Key: "missing", FromAttribute: "nope", Action: Upsert},
+	)
+
+	status, ok := tagByKey(span, "status")
+	require.True(t, ok)
+	assert.Equal(t, int64(404), status.VInt64)
+	_, ok = tagByKey(span, "missing")
+	assert.False(t, ok)
+}
+
+func TestAttributesProcessorHash(t *testing.T) {
+	span := &model.Span{Tags: []model.KeyValue{
+		{Key: "user.email", VType: model.StringType, VStr: "jane@example.com"},
+		{Key: "user.id", VType: model.Int64Type, VInt64: 42},
+		{Key: "http.method", VType: model.StringType, VStr: "GET"},
+	}}
+	applyActions(t, span, AttributeAction{KeyGlob: "user.*", Action: Hash})
+
+	email, _ := tagByKey(span, "user.email")
+	assert.Equal(t, model.StringType, email.VType)
+	assert.Equal(t, "8c87b489ce35cf2e2f39f80e282cb2e804932a56a213983eeeb428407d43b52d", email.VStr)
+
+	id, _ := tagByKey(span, "user.id")
+	assert.Equal(t, model.StringType, id.VType)
+	assert.Equal(t, "73475cb40a568e8da8a045ced110137e159f890ac4da883b6b17dc651b3a8049", id.VStr)
+
+	method, _ := tagByKey(span, "http.method")
+	assert.Equal(t, "GET", method.VStr)
+}
+
+func TestAttributesProcessorExtract(t *testing.T) {
+	span := &model.Span{Tags: []model.KeyValue{
+		{Key: "http.url", VType: model.StringType, VStr: "https://shop.example.com/api/v2/orders"},
+	}}
+	applyActions(t, span, AttributeAction{
+		Key:     "http.url",
+		Pattern: `^https?://(?P<http_host>[^/]+)/api/(?P<api_version>v\d+)/`,
+		Action:  Extract,
+	})
+
+	host, _ := tagByKey(span, "http_host")
+	assert.Equal(t, "shop.example.com", host.VStr)
+	version, _ := tagByKey(span, "api_version")
+	assert.Equal(t, "v2", version.VStr)
+}
+
+func TestAttributesProcessorConvert(t *testing.T) {
+	span := &model.Span{Tags: []model.KeyValue{
+		{Key: "http.status_code", VType: model.StringType, VStr: "503"},
+		{Key: "cache.hit", VType: model.StringType, VStr: "false"},
+		{Key: "db.rows", VType: model.StringType, VStr: "many"},
+	}}
+	applyActions(t, span,
+		AttributeAction{Key: "http.status_code", ConvertedType: model.Int64Type, Action: Convert},
+		AttributeAction{KeyRegex: `^(cache\.hit|db\.rows)$`, ConvertedType: model.BoolType, Action: Convert},
+	)
+
+	status, _ := tagByKey(span, "http.status_code")
+	assert.Equal(t, model.KeyValue{Key: "http.status_code", VType: model.Int64Type, VInt64: 503}, status)
+	hit, _ := tagByKey(span, "cache.hit")
+	assert.Equal(t, model.BoolType, hit.VType)
+	// Unconvertible values are left untouched
+	rows, _ := tagByKey(span, "db.rows")
+	assert.Equal(t, model.KeyValue{Key: "db.rows", VType: model.StringType, VStr: "many"}, rows)
+}
+
+func TestAttributesProcessorDeleteByRegex(t *testing.T) {
+	span := &model.Span{Tags: []model.KeyValue{
+		{Key: "internal.debug", VType: model.StringType, VStr: "a"},
+		{Key: "internal.trace", VType: model.StringType, VStr: "b"},
+		{Key: "http.method", VType: model.StringType, VStr: "GET"},
+	}}
+	applyActions(t, span, AttributeAction{KeyRegex: `^internal\.`, Action: Delete})
+
+	assert.Equal(t, []model.KeyValue{{Key: "http.method", VType: model.StringType, VStr: "GET"}}, span.Tags)
+}
+
+func TestAttributesProcessorIndexedTags(t *testing.T) {
+	newSpan := func() *model.Span {
+		span := &model.Span{}
+		for i := 0; i < tagIndexThreshold; i++ {
+			span.Tags = append(span.Tags, model.Int64(fmt.Sprintf("tag.%02d", i), int64(i)))
+		}
+		span.SetTag(model.String("url", "/users/42"))
+		return span
+	}
+	actions := []AttributeAction{
+		{KeyGlob: "tag.0*", Action: Delete},
+		{Key: "tag.15", FromAttribute: "tag.12", Action: Update},
+		{Key: "url", Pattern: `/users/(?P<user_id>\d+)`, Action: Extract},
+		{Key: "user", FromAttribute: "user_id", Action: Insert},
+		{Key: "tag.11", Value: "x", Action: Insert},
+	}
+	processor, err := NewAttributesProcessor("test-attributes", AttributesConfig{Actions: actions})
+	require.NoError(t, err)
+
+	in := make(chan *model.Span, 1)
+	in <- newSpan()
+	close(in)
+	indexed := <-processor.Process(context.Background(), in)
+
+	// Applying the actions with linear lookups gives the same tags
+	plain := newSpan()
+	applyActions(t, plain, actions...)
+	assert.Equal(t, plain.Tags, indexed.Tags)
+
+	user, ok := indexed.Tag("user")
+	require.True(t, ok)
+	assert.Equal(t, "42", user.VStr)
+	tag15, _ := indexed.Tag("tag.15")
+	assert.Equal(t, int64(12), tag15.VInt64)
+	tag11, _ := indexed.Tag("tag.11")
+	assert.Equal(t, model.Int64Type, tag11.VType)
+}
+
+func TestAttributesProcessorInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		action AttributeAction
+	}{
+		{name: "unknown action", action: AttributeAction{Key: "k", Action: "rename"}},
+		{name: "bad key regex", action: AttributeAction{KeyRegex: "(", Action: Delete}},
+		{name: "bad glob", action: AttributeAction{KeyGlob: "[", Action: Hash}},
+		{name: "extract without groups", action: AttributeAction{Key: "k", Pattern: ".*", Action: Extract}},
+		{name: "bad typed value", action: AttributeAction{Key: "k", Value: "x", Type: model.Int64Type, Action: Insert}},
+		{name: "bad converted type", action: AttributeAction{Key: "k", ConvertedType: model.BinaryType, Action: Convert}},
+		{name: "no key", action: AttributeAction{Value: "v", Action: Upsert}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAttributesProcessor("test", AttributesConfig{Actions: []AttributeAction{tt.action}})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAttributesProcessorProcessChannel(t *testing.T) {
+	processor, err := NewAttributesProcessor("test", AttributesConfig{Actions: []AttributeAction{
+		{Key: "env", Value: "prod", Action: Insert},
+	}})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	in := make(chan *model.Span, 1)
+	out := processor.Process(ctx, in)
+	in <- &model.Span{}
+	close(in)
+
+	span := <-out
+	env, ok := tagByKey(span, "env")
+	assert.True(t, ok)
+	assert.Equal(t, "prod", env.VStr)
+}
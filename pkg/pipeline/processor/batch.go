@@ -2,87 +2,240 @@ package processor
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
 )
 
-// BatchProcessor batches spans for efficient processing
+// BatchProcessor groups spans into batches for efficient export. It is a
+// pipeline.Connector from spans to span slices: a batch is emitted when
+// it reaches SendBatchSize spans or SendBatchMaxBytes, or Timeout after
+// its first span.
+//
+// With a KeySource, spans are batched separately per key value (e.g. per
+// tenant), so every batch is homogeneous. At most MaxBatchers keys are
+// batched at once; a new key beyond that flushes the oldest batch first.
+//
+// Spans leave without the tags the receiver recorded from the connection
+// (the peer IP and metadata.* request headers), unless KeepMetadata is
+// set.
 type BatchProcessor struct {
-	name          string
-	timeout       time.Duration
-	batchSize     int
-	sendBatchSize int
+	name              string
+	timeout           time.Duration
+	batchSize         int
+	sendBatchSize     int
+	sendBatchMaxBytes int
+	keySource         BatchKeySource
+	key               string
+	maxBatchers       int
+	keepMetadata      bool
+	metrics           *observability.Metrics
+	now               func() time.Time
 }
 
+// BatchKeySource selects what batches are keyed by
+type BatchKeySource string
+
+const (
+	// KeyNone puts all spans into one batch
+	KeyNone BatchKeySource = ""
+	// KeyTag batches by the value of a span tag, then process tag
+	KeyTag BatchKeySource = "tag"
+	// KeyService batches by Process.ServiceName
+	KeyService BatchKeySource = "service"
+	// KeyMetadata batches by an incoming request header, recorded by the
+	// receiver as a span tag (see model.TagMetadataPrefix)
+	KeyMetadata BatchKeySource = "metadata"
+)
+
 // BatchConfig configures the batch processor
 type BatchConfig struct {
-	Timeout       time.Duration
-	BatchSize     int
-	SendBatchSize int
+	Timeout           time.Duration
+	BatchSize         int                    // Spans buffered downstream
+	SendBatchSize     int                    // Spans per batch
+	SendBatchMaxBytes int                    // Estimated bytes per batch (0 = unlimited, see model.Span.Size)
+	KeySource         BatchKeySource         // What to batch by (default: nothing)
+	Key               string                 // Tag key (KeyTag) or header name (KeyMetadata)
+	MaxBatchers       int                    // Keys batched at once; the oldest batch is flushed to make room
+	KeepMetadata      bool                   // Keep receiver peer IP and metadata.* tags on exported spans
+	Metrics           *observability.Metrics // Optional; counts batches flushed early to make room
 }
 
 // DefaultBatchConfig returns default batch configuration
 func DefaultBatchConfig() BatchConfig {
 	return BatchConfig{
-		Timeout:       1 * time.Second,
-		BatchSize:     8192,
-		SendBatchSize: 1024,
+		Timeout:           1 * time.Second,
+		BatchSize:         8192,
+		SendBatchSize:     1024,
+		SendBatchMaxBytes: 4 * 1024 * 1024, // gRPC's default message limit
+		MaxBatchers:       1000,
 	}
 }
 
+// batcher accumulates one batch
+type batcher struct {
+	key     string
+	spans   []*model.Span
+	bytes   int
+	started time.Time
+}
+
 // NewBatchProcessor creates a new batch processor
-func NewBatchProcessor(name string, config BatchConfig) *BatchProcessor {
-	return &BatchProcessor{
-		name:          name,
-		timeout:       config.Timeout,
-		batchSize:     config.BatchSize,
-		sendBatchSize: config.SendBatchSize,
+func NewBatchProcessor(name string, config BatchConfig) (*BatchProcessor, error) {
+	defaults := DefaultBatchConfig()
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.SendBatchSize <= 0 {
+		config.SendBatchSize = defaults.SendBatchSize
 	}
+	if config.MaxBatchers <= 0 {
+		config.MaxBatchers = defaults.MaxBatchers
+	}
+
+	switch config.KeySource {
+	case KeyNone, KeyService:
+	case KeyTag, KeyMetadata:
+		if config.Key == "" {
+			return nil, fmt.Errorf("batch key source %s requires a key", config.KeySource)
+		}
+		if config.KeySource == KeyMetadata {
+			config.Key = strings.ToLower(config.Key) // Header names are case-insensitive
+		}
+	default:
+		return nil, fmt.Errorf("unknown batch key source: %s", config.KeySource)
+	}
+
+	return &BatchProcessor{
+		name:              name,
+		timeout:           config.Timeout,
+		batchSize:         config.BatchSize,
+		sendBatchSize:     config.SendBatchSize,
+		sendBatchMaxBytes: config.SendBatchMaxBytes,
+		keySource:         config.KeySource,
+		key:               config.Key,
+		maxBatchers:       config.MaxBatchers,
+		keepMetadata:      config.KeepMetadata,
+		metrics:           config.Metrics,
+		now:               time.Now,
+	}, nil
 }
 
 // Process batches incoming spans
-func (p *BatchProcessor) Process(ctx context.Context, in <-chan *model.Span) <-chan *model.Span {
-	out := make(chan *model.Span, p.batchSize)
+func (p *BatchProcessor) Process(ctx context.Context, in <-chan *model.Span) <-chan []*model.Span {
+	buffered := p.batchSize / p.sendBatchSize
+	if buffered < 1 {
+		buffered = 1
+	}
+	out := make(chan []*model.Span, buffered)
 
 	go func() {
 		defer close(out)
 
-		batch := make([]*model.Span, 0, p.sendBatchSize)
-		ticker := time.NewTicker(p.timeout)
-		defer ticker.Stop()
+		batchers := make(map[string]*batcher)
+		// Batchers in creation order, for evicting the oldest. Entries
+		// of flushed batchers are skipped and compacted away.
+		var order []*batcher
 
-		flush := func() {
-			for _, span := range batch {
-				select {
-				case out <- span:
-				case <-ctx.Done():
-					return
-				}
+		// flush sends a batcher's spans and forgets it; keys come back
+		// with their next span
+		flush := func(key string) bool {
+			b := batchers[key]
+			delete(batchers, key)
+			if b == nil || len(b.spans) == 0 {
+				return true
 			}
-			batch = batch[:0] // Clear batch
+			select {
+			case out <- b.spans:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		// Timeouts are checked a few times per period so that each batch
+		// is flushed close to Timeout after its first span
+		interval := p.timeout / 4
+		if interval < time.Millisecond {
+			interval = time.Millisecond
 		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
 
 		for {
 			select {
 			case span, ok := <-in:
 				if !ok {
-					// Input closed, flush remaining batch
-					if len(batch) > 0 {
-						flush()
+					// Input closed, flush remaining batches
+					for key := range batchers {
+						if !flush(key) {
+							return
+						}
 					}
 					return
 				}
 
-				batch = append(batch, span)
-				if len(batch) >= p.sendBatchSize {
-					flush()
+				key := p.batchKey(span)
+				if !p.keepMetadata {
+					stripMetadata(span)
+				}
+				if _, ok := batchers[key]; !ok && len(batchers) >= p.maxBatchers {
+					// Flush the oldest batch rather than mixing keys
+					for len(order) > 0 {
+						oldest := order[0]
+						order = order[1:]
+						if batchers[oldest.key] == oldest {
+							if !flush(oldest.key) {
+								return
+							}
+							if p.metrics != nil {
+								p.metrics.AddCounter("batch."+p.name+".evicted", 1)
+							}
+							break
+						}
+					}
+				}
+
+				size := 0
+				if p.sendBatchMaxBytes > 0 {
+					size = span.Size()
+					// An oversized span still goes out, alone
+					if b, ok := batchers[key]; ok && b.bytes+size > p.sendBatchMaxBytes && !flush(key) {
+						return
+					}
+				}
+
+				b, ok := batchers[key]
+				if !ok {
+					b = &batcher{key: key, spans: make([]*model.Span, 0, p.sendBatchSize), started: p.now()}
+					batchers[key] = b
+					order = append(order, b)
+					if len(order) > 2*p.maxBatchers {
+						live := make([]*batcher, 0, len(batchers))
+						for _, o := range order {
+							if batchers[o.key] == o {
+								live = append(live, o)
+							}
+						}
+						order = live
+					}
+				}
+				b.spans = append(b.spans, span)
+				b.bytes += size
+				if len(b.spans) >= p.sendBatchSize && !flush(key) {
+					return
 				}
 
 			case <-ticker.C:
-				// Timeout, flush batch
-				if len(batch) > 0 {
-					flush()
+				// Flush batches whose timeout passed
+				deadline := p.now().Add(-p.timeout)
+				for key, b := range batchers {
+					if !b.started.After(deadline) && !flush(key) {
+						return
+					}
 				}
 
 			case <-ctx.Done():
@@ -94,6 +247,54 @@ func (p *BatchProcessor) Process(ctx context.Context, in <-chan *model.Span) <-c
 	return out
 }
 
+// batchKey returns the key a span is batched by
+func (p *BatchProcessor) batchKey(span *model.Span) string {
+	switch p.keySource {
+	case KeyService:
+		if span.Process != nil {
+			return span.Process.ServiceName
+		}
+	case KeyTag:
+		return spanOrProcessTag(span, p.key)
+	case KeyMetadata:
+		// Only the receiver sets span metadata tags; process tags come
+		// from the client
+		if idx := findTag(span.Tags, model.TagMetadataPrefix+p.key); idx >= 0 {
+			return span.Tags[idx].AsString()
+		}
+	}
+	return ""
+}
+
+// stripMetadata removes the receiver's peer IP and metadata tags from a
+// span
+func stripMetadata(span *model.Span) {
+	kept := span.Tags[:0]
+	for _, tag := range span.Tags {
+		if tag.Key != model.TagPeerIP && !strings.HasPrefix(tag.Key, model.TagMetadataPrefix) {
+			kept = append(kept, tag)
+		}
+	}
+	if len(kept) == 0 {
+		kept = nil
+	}
+	span.Tags = kept
+}
+
+// spanOrProcessTag returns the string form of a span tag, falling back
+// to a process tag
+func spanOrProcessTag(span *model.Span, key string) string {
+	if idx := findTag(span.Tags, key); idx >= 0 {
+		return span.Tags[idx].AsString()
+	}
+	if span.Process != nil {
+		if idx := findTag(span.Process.Tags, key); idx >= 0 {
+			return span.Process.Tags[idx].AsString()
+		}
+	}
+	return ""
+}
+
 // Name returns the processor name
 func (p *BatchProcessor) Name() string {
 	return p.name
@@ -0,0 +1,234 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+func batchIDs(batch []*model.Span) []model.SpanID {
+	ids := make([]model.SpanID, len(batch))
+	for i, span := range batch {
+		ids[i] = span.SpanID
+	}
+	return ids
+}
+
+func TestBatchProcessorSendBatchSize(t *testing.T) {
+	processor, err := NewBatchProcessor("batch", BatchConfig{Timeout: time.Hour, SendBatchSize: 2})
+	require.NoError(t, err)
+
+	in := make(chan *model.Span, 5)
+	for i := 1; i <= 5; i++ {
+		in <- &model.Span{SpanID: model.SpanID(i)}
+	}
+	close(in)
+
+	var batches [][]model.SpanID
+	for batch := range processor.Process(context.Background(), in) {
+		batches = append(batches, batchIDs(batch))
+	}
+	assert.Equal(t, [][]model.SpanID{{1, 2}, {3, 4}, {5}}, batches)
+}
+
+func TestBatchProcessorSendBatchMaxBytes(t *testing.T) {
+	payload := strings.Repeat("x", 1000)
+	span := func(id model.SpanID) *model.Span {
+		return &model.Span{SpanID: id, Tags: []model.KeyValue{stringTag("payload", payload)}}
+	}
+	size := span(0).Size()
+
+	processor, err := NewBatchProcessor("batch", BatchConfig{
+		Timeout:           time.Hour,
+		SendBatchSize:     100,
+		SendBatchMaxBytes: 2*size + size/2,
+	})
+	require.NoError(t, err)
+
+	in := make(chan *model.Span, 5)
+	for i := 1; i <= 5; i++ {
+		in <- span(model.SpanID(i))
+	}
+	close(in)
+
+	var batches [][]model.SpanID
+	for batch := range processor.Process(context.Background(), in) {
+		batches = append(batches, batchIDs(batch))
+	}
+	assert.Equal(t, [][]model.SpanID{{1, 2}, {3, 4}, {5}}, batches)
+}
+
+func TestBatchProcessorTimeout(t *testing.T) {
+	processor, err := NewBatchProcessor("batch", BatchConfig{Timeout: 20 * time.Millisecond, SendBatchSize: 100})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	in := make(chan *model.Span, 2)
+	out := processor.Process(ctx, in)
+	in <- &model.Span{SpanID: 1}
+	in <- &model.Span{SpanID: 2}
+
+	select {
+	case batch := <-out:
+		require.Len(t, batch, 2)
+	case <-ctx.Done():
+		t.Fatal("batch was not flushed on timeout")
+	}
+}
+
+func TestBatchProcessorKeyedBatches(t *testing.T) {
+	tenantSpan := func(id model.SpanID, tenant string) *model.Span {
+		return &model.Span{SpanID: id, Tags: []model.KeyValue{
+			stringTag("metadata.x-tenant", tenant),
+			stringTag(model.TagPeerIP, "10.1.0.5"),
+		}}
+	}
+
+	processor, err := NewBatchProcessor("batch", BatchConfig{
+		Timeout:       time.Hour,
+		SendBatchSize: 2,
+		KeySource:     KeyMetadata,
+		Key:           "X-Tenant",
+	})
+	require.NoError(t, err)
+
+	in := make(chan *model.Span, 5)
+	in <- tenantSpan(1, "acme")
+	in <- tenantSpan(2, "globex")
+	in <- tenantSpan(3, "acme")
+	in <- tenantSpan(4, "globex")
+	in <- tenantSpan(5, "acme")
+	close(in)
+
+	var batches [][]model.SpanID
+	for batch := range processor.Process(context.Background(), in) {
+		batches = append(batches, batchIDs(batch))
+		for _, span := range batch {
+			assert.Empty(t, span.Tags, "request headers and peer IPs are not exported")
+		}
+	}
+	assert.Equal(t, [][]model.SpanID{{1, 3}, {2, 4}, {5}}, batches)
+}
+
+func TestBatchProcessorKeepMetadata(t *testing.T) {
+	processor, err := NewBatchProcessor("batch", BatchConfig{
+		Timeout:       time.Hour,
+		SendBatchSize: 1,
+		KeepMetadata:  true,
+	})
+	require.NoError(t, err)
+
+	in := make(chan *model.Span, 1)
+	in <- &model.Span{SpanID: 1, Tags: []model.KeyValue{
+		stringTag("metadata.x-tenant", "acme"),
+		stringTag(model.TagPeerIP, "10.1.0.5"),
+		stringTag("k", "v"),
+	}}
+	close(in)
+
+	batch := <-processor.Process(context.Background(), in)
+	require.Len(t, batch, 1)
+	assert.Len(t, batch[0].Tags, 3)
+}
+
+func TestBatchProcessorMaxBatchers(t *testing.T) {
+	metrics := observability.NewMetrics()
+	processor, err := NewBatchProcessor("batch", BatchConfig{
+		Timeout:       time.Hour,
+		SendBatchSize: 10,
+		KeySource:     KeyService,
+		MaxBatchers:   2,
+		Metrics:       metrics,
+	})
+	require.NoError(t, err)
+
+	in := make(chan *model.Span, 5)
+	for i, service := range []string{"a", "b", "c", "d", "a"} {
+		in <- &model.Span{SpanID: model.SpanID(i + 1), Process: &model.Process{ServiceName: service}}
+	}
+	close(in)
+
+	var batches [][]model.SpanID
+	for batch := range processor.Process(context.Background(), in) {
+		batches = append(batches, batchIDs(batch))
+	}
+	// Each new key beyond two flushes the oldest batch; keys never mix
+	assert.Equal(t, [][]model.SpanID{{1}, {2}, {3}}, batches[:3])
+	assert.ElementsMatch(t, [][]model.SpanID{{4}, {5}}, batches[3:])
+	assert.Equal(t, uint64(3), metrics.Snapshot().Counters["batch.batch.evicted"])
+}
+
+func TestBatchProcessorPerKeyTimeout(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var mu sync.Mutex
+	processor, err := NewBatchProcessor("batch", BatchConfig{
+		Timeout:       20 * time.Millisecond,
+		SendBatchSize: 10,
+		KeySource:     KeyTag,
+		Key:           "tenant",
+	})
+	require.NoError(t, err)
+	processor.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	in := make(chan *model.Span)
+	out := processor.Process(ctx, in)
+
+	// The second send of each pair returns only once the first span has
+	// been batched, so the clock moves between batches
+	in <- &model.Span{SpanID: 1, Tags: []model.KeyValue{stringTag("tenant", "acme")}}
+	in <- &model.Span{SpanID: 2, Tags: []model.KeyValue{stringTag("tenant", "acme")}}
+	advance(15 * time.Millisecond)
+	in <- &model.Span{SpanID: 3, Tags: []model.KeyValue{stringTag("tenant", "globex")}}
+	in <- &model.Span{SpanID: 4, Tags: []model.KeyValue{stringTag("tenant", "globex")}}
+	advance(10 * time.Millisecond)
+
+	// Only acme's batch is past its timeout
+	select {
+	case batch := <-out:
+		assert.Equal(t, []model.SpanID{1, 2}, batchIDs(batch))
+	case <-ctx.Done():
+		t.Fatal("batch was not flushed on timeout")
+	}
+	select {
+	case batch := <-out:
+		t.Fatalf("unexpected batch %v", batchIDs(batch))
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	advance(10 * time.Millisecond)
+	select {
+	case batch := <-out:
+		assert.Equal(t, []model.SpanID{3, 4}, batchIDs(batch))
+	case <-ctx.Done():
+		t.Fatal("batch was not flushed on timeout")
+	}
+}
+
+func TestNewBatchProcessorValidates(t *testing.T) {
+	_, err := NewBatchProcessor("batch", BatchConfig{KeySource: KeyTag})
+	assert.Error(t, err)
+
+	_, err = NewBatchProcessor("batch", BatchConfig{KeySource: "header"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,191 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+)
+
+// ClockSkewProcessor corrects child spans recorded on hosts whose clocks
+// drift from their parent's. It works on assembled traces (see
+// GroupByTraceProcessor) and mirrors Jaeger's query-side adjuster:
+//
+//   - a child on the same host inherits its parent's adjustment
+//   - a child on another host that falls outside the parent's
+//     [StartTime, StartTime+Duration] window is shifted: if it is longer
+//     than the parent the start times are aligned, otherwise it is
+//     centered in the parent, splitting the network latency evenly
+//
+// Every shifted span gets a note in Span.Warnings.
+type ClockSkewProcessor struct {
+	name          string
+	hostTags      []string
+	maxAdjustment time.Duration
+}
+
+// ClockSkewConfig configures the clock skew processor
+type ClockSkewConfig struct {
+	HostTags      []string      // Process tags identifying the host, in order of preference
+	MaxAdjustment time.Duration // Larger adjustments are skipped with a warning (0 = unlimited)
+}
+
+// DefaultClockSkewConfig returns default clock skew configuration
+func DefaultClockSkewConfig() ClockSkewConfig {
+	return ClockSkewConfig{
+		HostTags: []string{"ip", "host.ip", "hostname", "host.name"},
+	}
+}
+
+// NewClockSkewProcessor creates a new clock skew processor
+func NewClockSkewProcessor(name string, config ClockSkewConfig) *ClockSkewProcessor {
+	if len(config.HostTags) == 0 {
+		config.HostTags = DefaultClockSkewConfig().HostTags
+	}
+	return &ClockSkewProcessor{
+		name:          name,
+		hostTags:      config.HostTags,
+		maxAdjustment: config.MaxAdjustment,
+	}
+}
+
+// Process adjusts each trace in place
+func (p *ClockSkewProcessor) Process(ctx context.Context, in <-chan *model.Trace) <-chan *model.Trace {
+	out := make(chan *model.Trace)
+
+	go func() {
+		defer close(out)
+
+		for {
+			select {
+			case trace, ok := <-in:
+				if !ok {
+					return
+				}
+
+				p.adjust(trace)
+
+				select {
+				case out <- trace:
+				case <-ctx.Done():
+					return
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// adjust walks the span tree from its roots, propagating skew downwards
+func (p *ClockSkewProcessor) adjust(trace *model.Trace) {
+	spans := make(map[model.SpanID]*model.Span, len(trace.Spans))
+	for _, span := range trace.Spans {
+		spans[span.SpanID] = span
+	}
+
+	children := make(map[model.SpanID][]*model.Span)
+	roots := make([]*model.Span, 0)
+	for _, span := range trace.Spans {
+		parent, ok := parentKey(span)
+		if !ok || spans[parent.spanID] == nil || parent.spanID == span.SpanID {
+			roots = append(roots, span)
+			continue
+		}
+		children[parent.spanID] = append(children[parent.spanID], span)
+	}
+
+	visited := make(map[model.SpanID]bool, len(trace.Spans))
+	var walk func(parent *model.Span, skew time.Duration)
+	walk = func(parent *model.Span, skew time.Duration) {
+		for _, child := range children[parent.SpanID] {
+			if visited[child.SpanID] {
+				continue // Cycle
+			}
+			visited[child.SpanID] = true
+
+			childSkew := p.skew(trace, parent, child, skew)
+			p.shift(child, childSkew)
+			walk(child, childSkew)
+		}
+	}
+	for _, root := range roots {
+		visited[root.SpanID] = true
+		walk(root, 0)
+	}
+}
+
+// skew returns the adjustment for child given its (already adjusted) parent
+func (p *ClockSkewProcessor) skew(trace *model.Trace, parent, child *model.Span, parentSkew time.Duration) time.Duration {
+	parentHost := p.hostKey(trace, parent)
+	childHost := p.hostKey(trace, child)
+	if parentHost == "" || childHost == "" {
+		return 0 // Unknown hosts: nothing to compare
+	}
+	if parentHost == childHost {
+		return parentSkew // Same clock as the parent
+	}
+
+	parentEnd := parent.StartTime.Add(parent.Duration)
+	childEnd := child.StartTime.Add(child.Duration)
+	if !child.StartTime.Before(parent.StartTime) && !childEnd.After(parentEnd) {
+		return 0 // Fits within the parent
+	}
+
+	if child.Duration > parent.Duration {
+		// Child is longer than its parent: align the start times
+		return parent.StartTime.Sub(child.StartTime)
+	}
+	// Center the child, splitting the latency between request and response
+	latency := (parent.Duration - child.Duration) / 2
+	return parent.StartTime.Add(latency).Sub(child.StartTime)
+}
+
+// shift moves a span and its logs by skew, recording a warning
+func (p *ClockSkewProcessor) shift(span *model.Span, skew time.Duration) {
+	if skew == 0 {
+		return
+	}
+	if p.maxAdjustment > 0 && (skew > p.maxAdjustment || skew < -p.maxAdjustment) {
+		span.Warnings = append(span.Warnings, fmt.Sprintf(
+			"max clock skew adjustment delta of %v exceeded; not applying calculated delta of %v", p.maxAdjustment, skew))
+		return
+	}
+
+	span.StartTime = span.StartTime.Add(skew)
+	for i := range span.Logs {
+		span.Logs[i].Timestamp = span.Logs[i].Timestamp.Add(skew)
+	}
+	span.Warnings = append(span.Warnings, fmt.Sprintf("This span's timestamps were adjusted by %v", skew))
+}
+
+// hostKey identifies the host that recorded a span
+func (p *ClockSkewProcessor) hostKey(trace *model.Trace, span *model.Span) string {
+	process := span.Process
+	if process == nil && span.ProcessID != "" {
+		process = trace.ProcessByID(span.ProcessID)
+	}
+	if process == nil {
+		return ""
+	}
+	for _, key := range p.hostTags {
+		if idx := findTag(process.Tags, key); idx >= 0 {
+			return process.Tags[idx].AsString()
+		}
+	}
+	return ""
+}
+
+// Name returns the processor name
+func (p *ClockSkewProcessor) Name() string {
+	return p.name
+}
+
+// MutatesData reports that the processor modifies spans in place
+func (p *ClockSkewProcessor) MutatesData() bool {
+	return true
+}
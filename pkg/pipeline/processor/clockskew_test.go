@@ -0,0 +1,99 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+)
+
+func hostProcess(service, ip string) *model.Process {
+	return &model.Process{
+		ServiceName: service,
+		Tags:        []model.KeyValue{{Key: "ip", VType: model.StringType, VStr: ip}},
+	}
+}
+
+func TestClockSkewProcessorAdjustsAcrossHosts(t *testing.T) {
+	base := time.Unix(1000, 0)
+	traceID := model.TraceID{Low: 1}
+
+	root := &model.Span{TraceID: traceID, SpanID: 1, StartTime: base, Duration: 100 * time.Millisecond,
+		Process: hostProcess("frontend", "10.0.0.1")}
+	// Remote child starts 1s before its parent and is shorter: centered
+	remote := &model.Span{TraceID: traceID, SpanID: 2, ParentSpanID: 1, StartTime: base.Add(-time.Second), Duration: 40 * time.Millisecond,
+		Process: hostProcess("backend", "10.0.0.2"),
+		Logs:    []model.Log{{Timestamp: base.Add(-time.Second + 10*time.Millisecond)}}}
+	// Same host as remote: inherits its skew
+	local := &model.Span{TraceID: traceID, SpanID: 3, ParentSpanID: 2, StartTime: base.Add(-time.Second + 5*time.Millisecond), Duration: 10 * time.Millisecond,
+		Process: hostProcess("backend", "10.0.0.2")}
+	// Remote child within its parent: untouched
+	inside := &model.Span{TraceID: traceID, SpanID: 4, ParentSpanID: 1, StartTime: base.Add(10 * time.Millisecond), Duration: 10 * time.Millisecond,
+		Process: hostProcess("cache", "10.0.0.3")}
+	// Remote child longer than its parent: start times aligned
+	longer := &model.Span{TraceID: traceID, SpanID: 5, ParentSpanID: 1, StartTime: base.Add(time.Second), Duration: time.Second,
+		Process: hostProcess("worker", "10.0.0.4")}
+
+	trace := model.NewTrace(traceID)
+	for _, span := range []*model.Span{local, remote, root, inside, longer} {
+		trace.AddSpan(span)
+	}
+
+	processor := NewClockSkewProcessor("skew", DefaultClockSkewConfig())
+	in := make(chan *model.Trace, 1)
+	in <- trace
+	close(in)
+
+	var result []*model.Trace
+	for tr := range processor.Process(context.Background(), in) {
+		result = append(result, tr)
+	}
+	require.Len(t, result, 1)
+
+	assert.Equal(t, base.Add(30*time.Millisecond), remote.StartTime)
+	assert.Equal(t, base.Add(40*time.Millisecond), remote.Logs[0].Timestamp)
+	require.Len(t, remote.Warnings, 1)
+	assert.Contains(t, remote.Warnings[0], "adjusted by 1.03s")
+
+	assert.Equal(t, base.Add(35*time.Millisecond), local.StartTime)
+	assert.Len(t, local.Warnings, 1)
+
+	assert.Equal(t, base.Add(10*time.Millisecond), inside.StartTime)
+	assert.Empty(t, inside.Warnings)
+
+	assert.Equal(t, base, longer.StartTime)
+	assert.Empty(t, root.Warnings)
+}
+
+func TestClockSkewProcessorLimits(t *testing.T) {
+	base := time.Unix(1000, 0)
+	traceID := model.TraceID{Low: 1}
+
+	root := &model.Span{TraceID: traceID, SpanID: 1, StartTime: base, Duration: 100 * time.Millisecond,
+		Process: hostProcess("frontend", "10.0.0.1")}
+	farOff := &model.Span{TraceID: traceID, SpanID: 2, ParentSpanID: 1, StartTime: base.Add(-time.Hour), Duration: 10 * time.Millisecond,
+		Process: hostProcess("backend", "10.0.0.2")}
+	// No host information: cannot compare clocks
+	unknown := &model.Span{TraceID: traceID, SpanID: 3, ParentSpanID: 1, StartTime: base.Add(-time.Second), Duration: 10 * time.Millisecond,
+		Process: &model.Process{ServiceName: "legacy"}}
+
+	trace := model.NewTrace(traceID)
+	for _, span := range []*model.Span{root, farOff, unknown} {
+		trace.AddSpan(span)
+	}
+
+	config := DefaultClockSkewConfig()
+	config.MaxAdjustment = time.Minute
+	processor := NewClockSkewProcessor("skew", config)
+	processor.adjust(trace)
+
+	assert.Equal(t, base.Add(-time.Hour), farOff.StartTime)
+	require.Len(t, farOff.Warnings, 1)
+	assert.Contains(t, farOff.Warnings[0], "max clock skew adjustment delta of 1m0s exceeded")
+
+	assert.Equal(t, base.Add(-time.Second), unknown.StartTime)
+	assert.Empty(t, unknown.Warnings)
+}
@@ -0,0 +1,294 @@
+package processor
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+// DedupProcessor drops spans whose TraceID/SpanID was already seen, as
+// produced by SDK retries and redundant agents.
+//
+// Seen spans are remembered in a sharded LRU set bounded both in size
+// (MaxSpans) and in time (TTL). With Merge enabled, spans are held for
+// MergeWindow so that tags and logs carried only by their duplicates can
+// be merged in before the span is passed on.
+type DedupProcessor struct {
+	name        string
+	merge       bool
+	mergeWindow time.Duration
+	metrics     *observability.Metrics
+	seen        *seenSet
+	now         func() time.Time
+}
+
+// DedupConfig configures the dedup processor
+type DedupConfig struct {
+	TTL         time.Duration          // How long a span ID is remembered
+	MaxSpans    int                    // Span IDs remembered across all shards
+	Shards      int                    // Independently locked LRU shards
+	Merge       bool                   // Merge tags and logs of duplicates into the kept span
+	MergeWindow time.Duration          // How long spans are held for merging
+	Metrics     *observability.Metrics // Optional; counts removed and merged duplicates
+}
+
+// DefaultDedupConfig returns default dedup configuration
+func DefaultDedupConfig() DedupConfig {
+	return DedupConfig{
+		TTL:         5 * time.Minute,
+		MaxSpans:    1000000,
+		Shards:      16,
+		MergeWindow: 2 * time.Second,
+	}
+}
+
+// NewDedupProcessor creates a new dedup processor
+func NewDedupProcessor(name string, config DedupConfig) *DedupProcessor {
+	defaults := DefaultDedupConfig()
+	if config.TTL <= 0 {
+		config.TTL = defaults.TTL
+	}
+	if config.MaxSpans <= 0 {
+		config.MaxSpans = defaults.MaxSpans
+	}
+	if config.Shards <= 0 {
+		config.Shards = defaults.Shards
+	}
+	if config.MergeWindow <= 0 {
+		config.MergeWindow = defaults.MergeWindow
+	}
+
+	return &DedupProcessor{
+		name:        name,
+		merge:       config.Merge,
+		mergeWindow: config.MergeWindow,
+		metrics:     config.Metrics,
+		seen:        newSeenSet(config.Shards, config.MaxSpans, config.TTL),
+		now:         time.Now,
+	}
+}
+
+// heldSpan is a span waiting for duplicates to merge
+type heldSpan struct {
+	span     *model.Span
+	deadline time.Time
+}
+
+// Process drops duplicate spans
+func (p *DedupProcessor) Process(ctx context.Context, in <-chan *model.Span) <-chan *model.Span {
+	out := make(chan *model.Span, 100)
+
+	go func() {
+		defer close(out)
+
+		send := func(span *model.Span) bool {
+			select {
+			case out <- span:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		// Spans held for merging, in arrival order
+		held := list.New()
+		heldByKey := make(map[spanKey]*list.Element)
+
+		var tick <-chan time.Time
+		if p.merge {
+			interval := p.mergeWindow / 2
+			if interval < 10*time.Millisecond {
+				interval = 10 * time.Millisecond
+			}
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for {
+			select {
+			case span, ok := <-in:
+				if !ok {
+					for held.Len() > 0 {
+						front := held.Remove(held.Front()).(*heldSpan)
+						if !send(front.span) {
+							return
+						}
+					}
+					return
+				}
+
+				key := spanKey{traceID: span.TraceID, spanID: span.SpanID}
+				if !p.seen.Add(key, p.now()) {
+					if elem, ok := heldByKey[key]; ok {
+						mergeSpan(elem.Value.(*heldSpan).span, span)
+						p.count("merged")
+					}
+					p.count("duplicates")
+					continue
+				}
+
+				if !p.merge {
+					if !send(span) {
+						return
+					}
+					continue
+				}
+				heldByKey[key] = held.PushBack(&heldSpan{span: span, deadline: p.now().Add(p.mergeWindow)})
+
+			case <-tick:
+				now := p.now()
+				for held.Len() > 0 {
+					front := held.Front().Value.(*heldSpan)
+					if front.deadline.After(now) {
+						break
+					}
+					held.Remove(held.Front())
+					delete(heldByKey, spanKey{traceID: front.span.TraceID, spanID: front.span.SpanID})
+					if !send(front.span) {
+						return
+					}
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// count increments a dedup counter if metrics are enabled
+func (p *DedupProcessor) count(what string) {
+	if p.metrics != nil {
+		p.metrics.AddCounter("dedup."+p.name+"."+what, 1)
+	}
+}
+
+// mergeSpan copies tags with new keys and logs not already present from
+// a duplicate into the kept span
+func mergeSpan(kept, duplicate *model.Span) {
+	for _, tag := range duplicate.Tags {
+		if findTag(kept.Tags, tag.Key) < 0 {
+			kept.Tags = append(kept.Tags, tag)
+		}
+	}
+
+	logs := make(map[string]bool, len(kept.Logs))
+	for _, log := range kept.Logs {
+		logs[logKey(log)] = true
+	}
+	for _, log := range duplicate.Logs {
+		if key := logKey(log); !logs[key] {
+			logs[key] = true
+			kept.Logs = append(kept.Logs, log)
+		}
+	}
+}
+
+// logKey identifies a log by timestamp and fields
+func logKey(log model.Log) string {
+	key := log.Timestamp.UTC().Format(time.RFC3339Nano)
+	for _, field := range log.Fields {
+		key += "\x00" + field.Key + "=" + field.AsString()
+	}
+	return key
+}
+
+// Name returns the processor name
+func (p *DedupProcessor) Name() string {
+	return p.name
+}
+
+// MutatesData reports that the processor modifies spans in place
+func (p *DedupProcessor) MutatesData() bool {
+	return true
+}
+
+// seenSet is a sharded LRU set of span keys whose entries expire after
+// ttl. Safe for concurrent use.
+type seenSet struct {
+	shards []*seenShard
+	ttl    time.Duration
+}
+
+// seenShard is one independently locked LRU
+type seenShard struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // Least recently seen first
+	entries  map[spanKey]*list.Element
+}
+
+// seenEntry is a remembered key and when it was first seen
+type seenEntry struct {
+	key       spanKey
+	firstSeen time.Time
+}
+
+// newSeenSet creates a set holding about capacity keys
+func newSeenSet(shards, capacity int, ttl time.Duration) *seenSet {
+	perShard := capacity / shards
+	if perShard < 1 {
+		perShard = 1
+	}
+	s := &seenSet{shards: make([]*seenShard, shards), ttl: ttl}
+	for i := range s.shards {
+		s.shards[i] = &seenShard{
+			capacity: perShard,
+			order:    list.New(),
+			entries:  make(map[spanKey]*list.Element),
+		}
+	}
+	return s
+}
+
+// Add records a key and reports whether it was new. Keys first seen more
+// than ttl ago count as new.
+func (s *seenSet) Add(key spanKey, now time.Time) bool {
+	h := key.traceID.High ^ key.traceID.Low ^ uint64(key.spanID)*0x9e3779b97f4a7c15
+	shard := s.shards[h%uint64(len(s.shards))]
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if elem, ok := shard.entries[key]; ok {
+		entry := elem.Value.(*seenEntry)
+		if now.Sub(entry.firstSeen) < s.ttl {
+			shard.order.MoveToBack(elem)
+			return false
+		}
+		entry.firstSeen = now
+		shard.order.MoveToBack(elem)
+		return true
+	}
+
+	// Drop expired entries, then the least recently seen if still full
+	for shard.order.Len() > 0 {
+		front := shard.order.Front()
+		if now.Sub(front.Value.(*seenEntry).firstSeen) < s.ttl && shard.order.Len() < shard.capacity {
+			break
+		}
+		shard.order.Remove(front)
+		delete(shard.entries, front.Value.(*seenEntry).key)
+	}
+
+	shard.entries[key] = shard.order.PushBack(&seenEntry{key: key, firstSeen: now})
+	return true
+}
+
+// Len returns the number of remembered keys
+func (s *seenSet) Len() int {
+	n := 0
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		n += shard.order.Len()
+		shard.mu.Unlock()
+	}
+	return n
+}
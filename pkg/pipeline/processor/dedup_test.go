@@ -0,0 +1,105 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+func TestDedupProcessorDropsDuplicates(t *testing.T) {
+	metrics := observability.NewMetrics()
+	config := DefaultDedupConfig()
+	config.Metrics = metrics
+	processor := NewDedupProcessor("dedup", config)
+
+	in := make(chan *model.Span, 10)
+	in <- &model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 1}
+	in <- &model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 2}
+	in <- &model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 1}
+	in <- &model.Span{TraceID: model.TraceID{Low: 2}, SpanID: 1}
+	in <- &model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 2}
+	close(in)
+
+	var ids []model.SpanID
+	for span := range processor.Process(context.Background(), in) {
+		ids = append(ids, span.SpanID)
+	}
+
+	assert.Equal(t, []model.SpanID{1, 2, 1}, ids)
+	assert.Equal(t, uint64(2), metrics.Snapshot().Counters["dedup.dedup.duplicates"])
+}
+
+func TestDedupProcessorMergesDuplicates(t *testing.T) {
+	metrics := observability.NewMetrics()
+	config := DefaultDedupConfig()
+	config.Merge = true
+	config.MergeWindow = 20 * time.Millisecond
+	config.Metrics = metrics
+	processor := NewDedupProcessor("dedup", config)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	in := make(chan *model.Span, 10)
+	out := processor.Process(ctx, in)
+
+	logTime := time.Unix(1000, 0)
+	in <- &model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 1,
+		Tags: []model.KeyValue{stringTag("a", "1")},
+		Logs: []model.Log{{Timestamp: logTime, Fields: []model.KeyValue{stringTag("event", "retry")}}}}
+	in <- &model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 1,
+		Tags: []model.KeyValue{stringTag("a", "2"), stringTag("b", "1")},
+		Logs: []model.Log{
+			{Timestamp: logTime, Fields: []model.KeyValue{stringTag("event", "retry")}},
+			{Timestamp: logTime.Add(time.Millisecond), Fields: []model.KeyValue{stringTag("event", "done")}},
+		}}
+
+	// Released once the merge window passes, without closing the input
+	var span *model.Span
+	select {
+	case span = <-out:
+	case <-ctx.Done():
+		t.Fatal("span was not released")
+	}
+
+	require.Len(t, span.Tags, 2)
+	a, _ := tagByKey(span, "a")
+	assert.Equal(t, "1", a.VStr)
+	b, _ := tagByKey(span, "b")
+	assert.Equal(t, "1", b.VStr)
+	assert.Len(t, span.Logs, 2)
+
+	// Duplicates after release are still dropped
+	in <- &model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 1}
+	close(in)
+	for range out {
+		t.Fatal("duplicate was passed on")
+	}
+
+	counters := metrics.Snapshot().Counters
+	assert.Equal(t, uint64(2), counters["dedup.dedup.duplicates"])
+	assert.Equal(t, uint64(1), counters["dedup.dedup.merged"])
+}
+
+func TestSeenSetBounds(t *testing.T) {
+	now := time.Unix(1000, 0)
+	set := newSeenSet(1, 2, time.Minute)
+	key := func(id model.SpanID) spanKey { return spanKey{traceID: model.TraceID{Low: 1}, spanID: id} }
+
+	assert.True(t, set.Add(key(1), now))
+	assert.True(t, set.Add(key(2), now))
+	assert.False(t, set.Add(key(1), now))
+
+	// Capacity evicts the least recently seen key
+	assert.True(t, set.Add(key(3), now))
+	assert.Equal(t, 2, set.Len())
+	assert.True(t, set.Add(key(2), now))
+
+	// Expired keys are new again
+	assert.True(t, set.Add(key(2), now.Add(2*time.Minute)))
+}
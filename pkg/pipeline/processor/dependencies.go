@@ -0,0 +1,308 @@
+package processor
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+// DependencyProcessor derives the service dependency graph by matching
+// child spans to their parents across Process.ServiceName boundaries.
+// Spans pass through unchanged.
+//
+// Parents and children may arrive in any order: recently seen spans are
+// kept in a bounded store, and children whose parent has not arrived yet
+// wait in a bounded pending-edge store. Both evict oldest-first. Each
+// parent holds at most MaxChildrenPerParent waiting children, so a
+// missing parent with a large fan-out cannot grow the store.
+type DependencyProcessor struct {
+	name             string
+	snapshotInterval time.Duration
+	snapshots        *snapshotStream[[]DependencyLink]
+	maxChildren      int
+	metrics          *observability.Metrics
+
+	// Only touched by the processing goroutine
+	seen    *fifoMap[spanKey, string]
+	pending *fifoMap[spanKey, []pendingChild]
+
+	mu    sync.RWMutex
+	edges map[dependencyEdge]*DependencyLink
+}
+
+// DependencyConfig configures the dependency processor
+type DependencyConfig struct {
+	MaxSpans             int                    // Recently seen spans remembered for parent lookup
+	MaxPending           int                    // Parents awaited by early-arriving children
+	MaxChildrenPerParent int                    // Children waiting for one parent; later ones are dropped
+	SnapshotInterval     time.Duration          // Period of the snapshot stream (0 = disabled)
+	Metrics              *observability.Metrics // Optional; counts dropped pending children
+}
+
+// DefaultDependencyConfig returns default dependency configuration
+func DefaultDependencyConfig() DependencyConfig {
+	return DependencyConfig{
+		MaxSpans:             100000,
+		MaxPending:           10000,
+		MaxChildrenPerParent: 1000,
+		SnapshotInterval:     1 * time.Minute,
+	}
+}
+
+// DependencyLink is a caller -> callee edge, compatible with Jaeger's
+// /api/dependencies representation
+type DependencyLink struct {
+	Parent     string `json:"parent"`
+	Child      string `json:"child"`
+	CallCount  uint64 `json:"callCount"`
+	ErrorCount uint64 `json:"errorCount"`
+}
+
+// ErrorRate returns the share of failed calls on the edge
+func (l DependencyLink) ErrorRate() float64 {
+	if l.CallCount == 0 {
+		return 0
+	}
+	return float64(l.ErrorCount) / float64(l.CallCount)
+}
+
+// spanKey identifies a span within all traces
+type spanKey struct {
+	traceID model.TraceID
+	spanID  model.SpanID
+}
+
+// pendingChild is a child span waiting for its parent
+type pendingChild struct {
+	service string
+	isError bool
+}
+
+// dependencyEdge identifies a graph edge
+type dependencyEdge struct {
+	parent string
+	child  string
+}
+
+// NewDependencyProcessor creates a new dependency processor
+func NewDependencyProcessor(name string, config DependencyConfig) *DependencyProcessor {
+	defaults := DefaultDependencyConfig()
+	if config.MaxSpans <= 0 {
+		config.MaxSpans = defaults.MaxSpans
+	}
+	if config.MaxPending <= 0 {
+		config.MaxPending = defaults.MaxPending
+	}
+	if config.MaxChildrenPerParent <= 0 {
+		config.MaxChildrenPerParent = defaults.MaxChildrenPerParent
+	}
+
+	p := &DependencyProcessor{
+		name:             name,
+		snapshotInterval: config.SnapshotInterval,
+		snapshots:        newSnapshotStream[[]DependencyLink](),
+		maxChildren:      config.MaxChildrenPerParent,
+		metrics:          config.Metrics,
+		seen:             newFIFOMap[spanKey, string](config.MaxSpans),
+		pending:          newFIFOMap[spanKey, []pendingChild](config.MaxPending),
+		edges:            make(map[dependencyEdge]*DependencyLink),
+	}
+	p.pending.evicted = func(_ spanKey, children []pendingChild) {
+		p.countDropped(len(children))
+	}
+	return p
+}
+
+// Process records dependencies for each span and passes it on. It may
+// be called again after a previous call returned, but calls must not
+// overlap.
+func (p *DependencyProcessor) Process(ctx context.Context, in <-chan *model.Span) <-chan *model.Span {
+	out := make(chan *model.Span, 100)
+
+	p.snapshots.start()
+	go func() {
+		defer close(out)
+		defer p.snapshots.stop()
+
+		var tick <-chan time.Time
+		if p.snapshotInterval > 0 {
+			ticker := time.NewTicker(p.snapshotInterval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for {
+			select {
+			case span, ok := <-in:
+				if !ok {
+					p.publish()
+					return
+				}
+
+				p.record(span)
+
+				select {
+				case out <- span:
+				case <-ctx.Done():
+					return
+				}
+
+			case <-tick:
+				p.publish()
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// record matches a span with its parent and any waiting children
+func (p *DependencyProcessor) record(span *model.Span) {
+	service := ""
+	if span.Process != nil {
+		service = span.Process.ServiceName
+	}
+	self := spanKey{traceID: span.TraceID, spanID: span.SpanID}
+	p.seen.Put(self, service)
+
+	// Link to the parent, or wait for it
+	if parent, ok := parentKey(span); ok {
+		if parentService, found := p.seen.Get(parent); found {
+			p.addEdge(parentService, service, isErrorSpan(span))
+		} else if waiting, _ := p.pending.Get(parent); len(waiting) < p.maxChildren {
+			p.pending.Put(parent, append(waiting, pendingChild{service: service, isError: isErrorSpan(span)}))
+		} else {
+			p.countDropped(1)
+		}
+	}
+
+	// Resolve children that arrived first
+	if children, ok := p.pending.Get(self); ok {
+		for _, child := range children {
+			p.addEdge(service, child.service, child.isError)
+		}
+		p.pending.Delete(self)
+	}
+}
+
+// countDropped counts pending children given up on
+func (p *DependencyProcessor) countDropped(n int) {
+	if p.metrics != nil && n > 0 {
+		p.metrics.AddCounter("dependencies."+p.name+".dropped_children", uint64(n))
+	}
+}
+
+// parentKey returns the parent of a span from ParentSpanID or its
+// references, preferring CHILD_OF over FOLLOWS_FROM
+func parentKey(span *model.Span) (spanKey, bool) {
+	if span.ParentSpanID.IsValid() {
+		return spanKey{traceID: span.TraceID, spanID: span.ParentSpanID}, true
+	}
+	var follows *model.Reference
+	for i := range span.References {
+		ref := &span.References[i]
+		if ref.RefType == model.ChildOf {
+			return spanKey{traceID: ref.TraceID, spanID: ref.SpanID}, true
+		}
+		if follows == nil {
+			follows = ref
+		}
+	}
+	if follows != nil {
+		return spanKey{traceID: follows.TraceID, spanID: follows.SpanID}, true
+	}
+	return spanKey{}, false
+}
+
+// addEdge counts a call across a service boundary
+func (p *DependencyProcessor) addEdge(parent, child string, isError bool) {
+	if parent == child {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := dependencyEdge{parent: parent, child: child}
+	link, ok := p.edges[key]
+	if !ok {
+		link = &DependencyLink{Parent: parent, Child: child}
+		p.edges[key] = link
+	}
+	link.CallCount++
+	if isError {
+		link.ErrorCount++
+	}
+}
+
+// publish sends a snapshot without blocking
+func (p *DependencyProcessor) publish() {
+	p.snapshots.publish(p.Snapshot)
+}
+
+// Snapshots returns the periodic snapshot stream. A snapshot is emitted
+// every SnapshotInterval and when the input closes. The stream is closed
+// when the first Process call returns; later calls only update the graph.
+func (p *DependencyProcessor) Snapshots() <-chan []DependencyLink {
+	return p.snapshots.ch
+}
+
+// Snapshot returns all edges sorted by parent and child
+func (p *DependencyProcessor) Snapshot() []DependencyLink {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	links := make([]DependencyLink, 0, len(p.edges))
+	for _, link := range p.edges {
+		links = append(links, *link)
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].Parent != links[j].Parent {
+			return links[i].Parent < links[j].Parent
+		}
+		return links[i].Child < links[j].Child
+	})
+	return links
+}
+
+// ServeHTTP writes the graph as Prometheus series
+func (p *DependencyProcessor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", observability.PrometheusContentType)
+	links := p.Snapshot()
+
+	observability.WritePrometheusHeader(w, "traces_service_graph_request_total", "Calls between services", "counter")
+	for _, link := range links {
+		observability.WritePrometheusSample(w, "traces_service_graph_request_total", link.labels(), float64(link.CallCount))
+	}
+
+	observability.WritePrometheusHeader(w, "traces_service_graph_request_failed_total", "Failed calls between services", "counter")
+	for _, link := range links {
+		observability.WritePrometheusSample(w, "traces_service_graph_request_failed_total", link.labels(), float64(link.ErrorCount))
+	}
+}
+
+// labels returns the Prometheus labels of an edge
+func (l DependencyLink) labels() []observability.Label {
+	return []observability.Label{
+		{Name: "client", Value: l.Parent},
+		{Name: "server", Value: l.Child},
+	}
+}
+
+// Name returns the processor name
+func (p *DependencyProcessor) Name() string {
+	return p.name
+}
+
+// MutatesData reports that the processor only reads spans
+func (p *DependencyProcessor) MutatesData() bool {
+	return false
+}
@@ -0,0 +1,153 @@
+package processor
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+func depSpan(trace uint64, id, parent model.SpanID, service string) *model.Span {
+	return &model.Span{
+		TraceID:      model.TraceID{Low: trace},
+		SpanID:       id,
+		ParentSpanID: parent,
+		Process:      &model.Process{ServiceName: service},
+	}
+}
+
+func TestDependencyProcessorEdges(t *testing.T) {
+	processor := NewDependencyProcessor("graph", DefaultDependencyConfig())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	failed := depSpan(1, 4, 2, "inventory")
+	failed.Tags = []model.KeyValue{{Key: "error", VType: model.BoolType, VBool: true}}
+
+	referenced := depSpan(1, 5, 0, "notifications")
+	referenced.References = []model.Reference{{RefType: model.FollowsFrom, TraceID: referenced.TraceID, SpanID: 2}}
+
+	spans := []*model.Span{
+		depSpan(1, 1, 0, "frontend"),
+		depSpan(1, 2, 1, "checkout"),
+		depSpan(1, 3, 2, "checkout"), // Same service: no edge
+		failed,
+		// Child arrives before its parent
+		depSpan(2, 12, 11, "checkout"),
+		depSpan(2, 11, 0, "frontend"),
+		referenced,
+	}
+
+	in := make(chan *model.Span, len(spans))
+	out := processor.Process(ctx, in)
+	for _, span := range spans {
+		in <- span
+	}
+	close(in)
+
+	passed := 0
+	for range out {
+		passed++
+	}
+	assert.Equal(t, len(spans), passed)
+
+	links, ok := <-processor.Snapshots()
+	require.True(t, ok)
+	assert.Equal(t, []DependencyLink{
+		{Parent: "checkout", Child: "inventory", CallCount: 1, ErrorCount: 1},
+		{Parent: "checkout", Child: "notifications", CallCount: 1},
+		{Parent: "frontend", Child: "checkout", CallCount: 2},
+	}, links)
+	assert.Equal(t, 1.0, links[0].ErrorRate())
+	assert.Equal(t, 0, processor.pending.Len())
+}
+
+func TestDependencyProcessorBoundedPending(t *testing.T) {
+	config := DefaultDependencyConfig()
+	config.MaxPending = 2
+	processor := NewDependencyProcessor("graph", config)
+
+	// Three orphans waiting for different parents; the oldest is evicted
+	processor.record(depSpan(1, 10, 1, "a"))
+	processor.record(depSpan(1, 20, 2, "b"))
+	processor.record(depSpan(1, 30, 3, "c"))
+	assert.Equal(t, 2, processor.pending.Len())
+
+	processor.record(depSpan(1, 1, 0, "root"))
+	processor.record(depSpan(1, 3, 0, "root"))
+	assert.Equal(t, []DependencyLink{{Parent: "root", Child: "c", CallCount: 1}}, processor.Snapshot())
+}
+
+func TestDependencyProcessorBoundedChildrenPerParent(t *testing.T) {
+	metrics := observability.NewMetrics()
+	config := DefaultDependencyConfig()
+	config.MaxPending = 1
+	config.MaxChildrenPerParent = 2
+	config.Metrics = metrics
+	processor := NewDependencyProcessor("graph", config)
+
+	// A parent that never arrives keeps at most two children
+	for id := model.SpanID(10); id < 15; id++ {
+		processor.record(depSpan(1, id, 1, "worker"))
+	}
+	children, _ := processor.pending.Get(spanKey{traceID: model.TraceID{Low: 1}, spanID: 1})
+	assert.Len(t, children, 2)
+	assert.Equal(t, uint64(3), metrics.Snapshot().Counters["dependencies.graph.dropped_children"])
+
+	// Evicting the parent's entry drops its children too
+	processor.record(depSpan(1, 20, 2, "worker"))
+	assert.Equal(t, uint64(5), metrics.Snapshot().Counters["dependencies.graph.dropped_children"])
+}
+
+func TestDependencyProcessorRestart(t *testing.T) {
+	processor := NewDependencyProcessor("graph", DefaultDependencyConfig())
+	run := func(spans ...*model.Span) {
+		in := make(chan *model.Span, len(spans))
+		for _, span := range spans {
+			in <- span
+		}
+		close(in)
+		for range processor.Process(context.Background(), in) {
+		}
+	}
+
+	run(depSpan(1, 1, 0, "frontend"), depSpan(1, 2, 1, "checkout"))
+	for range processor.Snapshots() {
+	}
+	run(depSpan(2, 1, 0, "frontend"), depSpan(2, 2, 1, "checkout"))
+
+	assert.Equal(t, []DependencyLink{{Parent: "frontend", Child: "checkout", CallCount: 2}}, processor.Snapshot())
+}
+
+func TestDependencyProcessorPrometheus(t *testing.T) {
+	processor := NewDependencyProcessor("graph", DefaultDependencyConfig())
+	processor.record(depSpan(1, 1, 0, "frontend"))
+	processor.record(depSpan(1, 2, 1, "checkout"))
+
+	rec := httptest.NewRecorder()
+	processor.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics/dependencies", nil))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, `traces_service_graph_request_total{client="frontend",server="checkout"} 1`)
+	assert.Contains(t, body, `traces_service_graph_request_failed_total{client="frontend",server="checkout"} 0`)
+}
+
+func TestFIFOMapReinsertAfterDelete(t *testing.T) {
+	m := newFIFOMap[string, int](2)
+	m.Put("a", 1)
+	m.Delete("a")
+	m.Put("b", 2)
+	m.Put("a", 3) // Reuses the slot freed from the first "a"
+
+	// Evicting the first slot must not remove the re-inserted "a"
+	m.Put("c", 4)
+	v, ok := m.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+}
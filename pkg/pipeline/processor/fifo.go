@@ -0,0 +1,65 @@
+package processor
+
+// fifoMap is a map bounded to a fixed number of keys; inserting a new
+// key beyond capacity evicts the oldest. Not safe for concurrent use.
+type fifoMap[K comparable, V any] struct {
+	entries map[K]fifoEntry[V]
+	order   []K
+	next    int
+	size    int
+	evicted func(key K, value V) // Optional; called for each evicted key
+}
+
+// fifoEntry remembers the order slot of a key so that a slot freed by
+// Delete and later reused does not evict a re-inserted key
+type fifoEntry[V any] struct {
+	value V
+	slot  int
+}
+
+// newFIFOMap creates a map holding at most capacity keys
+func newFIFOMap[K comparable, V any](capacity int) *fifoMap[K, V] {
+	return &fifoMap[K, V]{
+		entries: make(map[K]fifoEntry[V]),
+		order:   make([]K, capacity),
+	}
+}
+
+// Get returns the value for key
+func (m *fifoMap[K, V]) Get(key K) (V, bool) {
+	e, ok := m.entries[key]
+	return e.value, ok
+}
+
+// Put stores a value, evicting the oldest key when full
+func (m *fifoMap[K, V]) Put(key K, value V) {
+	if e, ok := m.entries[key]; ok {
+		e.value = value
+		m.entries[key] = e
+		return
+	}
+	if m.size == len(m.order) {
+		old := m.order[m.next]
+		if e, ok := m.entries[old]; ok && e.slot == m.next {
+			delete(m.entries, old)
+			if m.evicted != nil {
+				m.evicted(old, e.value)
+			}
+		}
+	} else {
+		m.size++
+	}
+	m.order[m.next] = key
+	m.entries[key] = fifoEntry[V]{value: value, slot: m.next}
+	m.next = (m.next + 1) % len(m.order)
+}
+
+// Delete removes a key. Its slot is reclaimed when it comes up for eviction.
+func (m *fifoMap[K, V]) Delete(key K) {
+	delete(m.entries, key)
+}
+
+// Len returns the number of stored keys
+func (m *fifoMap[K, V]) Len() int {
+	return len(m.entries)
+}
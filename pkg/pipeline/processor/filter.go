@@ -0,0 +1,341 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/vjranagit/jaeger-toolkit/pkg/filter"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+// FilterProcessor drops spans matching configured rules. Rules with
+// DropTrace also drop the spans of the matching trace that arrive later;
+// spans already forwarded are not recalled. Use TraceFilterProcessor
+// after groupbytrace to drop whole traces.
+type FilterProcessor struct {
+	name    string
+	rules   []filterRule
+	metrics *observability.Metrics
+
+	// Traces marked for dropping (bounded, FIFO eviction), allocated on
+	// the first DropTrace match. Only touched by the processing goroutine.
+	maxTraces     int
+	droppedTraces map[model.TraceID]int
+	traceOrder    []model.TraceID
+	traceNext     int
+}
+
+// FilterRule describes spans to drop. All set criteria must match.
+type FilterRule struct {
+	Name           string            // Used in metrics
+	Service        string            // Exact Process.ServiceName
+	OperationRegex string            // Regex on OperationName
+	Tags           map[string]string // Tag key -> value (string form); "" matches any value
+	MinDuration    time.Duration     // Match spans at least this long
+	MaxDuration    time.Duration     // Match spans at most this long
+	Expression     string            // Filter expression (see pkg/filter)
+	DropTrace      bool              // Also drop the rest of the trace (see FilterProcessor)
+}
+
+// FilterConfig configures the filter processor
+type FilterConfig struct {
+	Rules     []FilterRule
+	MaxTraces int                    // Dropped trace IDs remembered for DropTrace rules
+	Metrics   *observability.Metrics // Optional; receives per-rule drop counters
+}
+
+// DefaultFilterConfig returns default filter configuration
+func DefaultFilterConfig() FilterConfig {
+	return FilterConfig{
+		MaxTraces: 100000,
+	}
+}
+
+// filterRule is a FilterRule with its patterns compiled
+type filterRule struct {
+	FilterRule
+	operation  *regexp.Regexp
+	expression *filter.Expression
+	counter    string
+}
+
+// NewFilterProcessor creates a new filter processor
+func NewFilterProcessor(name string, config FilterConfig) (*FilterProcessor, error) {
+	rules, err := compileFilterRules(name, config.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.MaxTraces <= 0 {
+		config.MaxTraces = DefaultFilterConfig().MaxTraces
+	}
+
+	return &FilterProcessor{
+		name:      name,
+		rules:     rules,
+		metrics:   config.Metrics,
+		maxTraces: config.MaxTraces,
+	}, nil
+}
+
+// compileFilterRules validates rules and compiles their patterns
+func compileFilterRules(name string, configured []FilterRule) ([]filterRule, error) {
+	rules := make([]filterRule, 0, len(configured))
+	for i, rule := range configured {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule%d", i)
+		}
+		if rule.Service == "" && rule.OperationRegex == "" && len(rule.Tags) == 0 &&
+			rule.MinDuration == 0 && rule.MaxDuration == 0 && rule.Expression == "" {
+			return nil, fmt.Errorf("rule %s: no criteria", rule.Name)
+		}
+		compiled := filterRule{
+			FilterRule: rule,
+			counter:    "filter." + name + ".dropped." + rule.Name,
+		}
+
+		if rule.OperationRegex != "" {
+			re, err := regexp.Compile(rule.OperationRegex)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: invalid operation regex: %w", rule.Name, err)
+			}
+			compiled.operation = re
+		}
+		if rule.Expression != "" {
+			expr, err := filter.Compile(rule.Expression)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", rule.Name, err)
+			}
+			compiled.expression = expr
+		}
+		if rule.MinDuration > 0 && rule.MaxDuration > 0 && rule.MinDuration > rule.MaxDuration {
+			return nil, fmt.Errorf("rule %s: min duration exceeds max duration", rule.Name)
+		}
+
+		rules = append(rules, compiled)
+	}
+	return rules, nil
+}
+
+// Process drops spans matching any rule
+func (p *FilterProcessor) Process(ctx context.Context, in <-chan *model.Span) <-chan *model.Span {
+	out := make(chan *model.Span, 100)
+
+	go func() {
+		defer close(out)
+
+		for {
+			select {
+			case span, ok := <-in:
+				if !ok {
+					return
+				}
+
+				if rule := p.match(span); rule >= 0 {
+					p.recordDrop(rule)
+					continue
+				}
+
+				select {
+				case out <- span:
+				case <-ctx.Done():
+					return
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// match returns the index of the rule dropping the span, or -1
+func (p *FilterProcessor) match(span *model.Span) int {
+	if rule, ok := p.droppedTraces[span.TraceID]; ok {
+		return rule
+	}
+
+	for i := range p.rules {
+		if p.rules[i].matches(span) {
+			if p.rules[i].DropTrace {
+				p.rememberTrace(span.TraceID, i)
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+// rememberTrace marks a trace as dropped, evicting the oldest entry
+func (p *FilterProcessor) rememberTrace(traceID model.TraceID, rule int) {
+	if p.traceOrder == nil {
+		p.droppedTraces = make(map[model.TraceID]int)
+		p.traceOrder = make([]model.TraceID, p.maxTraces)
+	}
+	if old := p.traceOrder[p.traceNext]; old.IsValid() {
+		delete(p.droppedTraces, old)
+	}
+	p.traceOrder[p.traceNext] = traceID
+	p.traceNext = (p.traceNext + 1) % len(p.traceOrder)
+	p.droppedTraces[traceID] = rule
+}
+
+// recordDrop counts a drop against its rule
+func (p *FilterProcessor) recordDrop(rule int) {
+	if p.metrics != nil {
+		p.metrics.AddCounter(p.rules[rule].counter, 1)
+	}
+}
+
+// TraceFilterProcessor applies filter rules to assembled traces, e.g.
+// after groupbytrace. A span matching a DropTrace rule drops its whole
+// trace; spans matching other rules are removed from the trace.
+type TraceFilterProcessor struct {
+	name    string
+	rules   []filterRule
+	metrics *observability.Metrics
+}
+
+// NewTraceFilterProcessor creates a filter processor for traces.
+// MaxTraces is not used.
+func NewTraceFilterProcessor(name string, config FilterConfig) (*TraceFilterProcessor, error) {
+	rules, err := compileFilterRules(name, config.Rules)
+	if err != nil {
+		return nil, err
+	}
+	return &TraceFilterProcessor{name: name, rules: rules, metrics: config.Metrics}, nil
+}
+
+// Process drops traces and spans matching any rule. Traces left without
+// spans are dropped.
+func (p *TraceFilterProcessor) Process(ctx context.Context, in <-chan *model.Trace) <-chan *model.Trace {
+	out := make(chan *model.Trace, 100)
+
+	go func() {
+		defer close(out)
+
+		for {
+			select {
+			case trace, ok := <-in:
+				if !ok {
+					return
+				}
+
+				if !p.filter(trace) {
+					continue
+				}
+
+				select {
+				case out <- trace:
+				case <-ctx.Done():
+					return
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// filter removes matching spans from the trace and reports whether
+// anything is left
+func (p *TraceFilterProcessor) filter(trace *model.Trace) bool {
+	matched := make([]int, len(trace.Spans))
+	dropped := 0
+	for i, span := range trace.Spans {
+		matched[i] = -1
+		for r := range p.rules {
+			if p.rules[r].matches(span) {
+				matched[i] = r
+				break
+			}
+		}
+		if matched[i] < 0 {
+			continue
+		}
+		dropped++
+		if p.rules[matched[i]].DropTrace {
+			// Every span is counted against the rule dropping the trace
+			p.recordDrop(matched[i], len(trace.Spans))
+			return false
+		}
+	}
+	if dropped == 0 {
+		return true
+	}
+
+	kept := make([]*model.Span, 0, len(trace.Spans)-dropped)
+	for i, span := range trace.Spans {
+		if matched[i] >= 0 {
+			p.recordDrop(matched[i], 1)
+			continue
+		}
+		kept = append(kept, span)
+	}
+	trace.Spans = kept
+	return len(kept) > 0
+}
+
+// recordDrop counts dropped spans against their rule
+func (p *TraceFilterProcessor) recordDrop(rule, spans int) {
+	if p.metrics != nil {
+		p.metrics.AddCounter(p.rules[rule].counter, uint64(spans))
+	}
+}
+
+// Name returns the processor name
+func (p *TraceFilterProcessor) Name() string {
+	return p.name
+}
+
+// MutatesData reports that the processor modifies traces in place
+func (p *TraceFilterProcessor) MutatesData() bool {
+	return true
+}
+
+// matches reports whether every configured criterion matches
+func (r *filterRule) matches(span *model.Span) bool {
+	if r.Service != "" && (span.Process == nil || span.Process.ServiceName != r.Service) {
+		return false
+	}
+	if r.operation != nil && !r.operation.MatchString(span.OperationName) {
+		return false
+	}
+	if r.MinDuration > 0 && span.Duration < r.MinDuration {
+		return false
+	}
+	if r.MaxDuration > 0 && span.Duration > r.MaxDuration {
+		return false
+	}
+	for key, want := range r.Tags {
+		idx := findTag(span.Tags, key)
+		if idx < 0 {
+			return false
+		}
+		if want != "" && span.Tags[idx].AsString() != want {
+			return false
+		}
+	}
+	if r.expression != nil && !r.expression.Match(span) {
+		return false
+	}
+	return true
+}
+
+// Name returns the processor name
+func (p *FilterProcessor) Name() string {
+	return p.name
+}
+
+// MutatesData reports that the processor only reads spans
+func (p *FilterProcessor) MutatesData() bool {
+	return false
+}
@@ -0,0 +1,165 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+func runFilter(t *testing.T, config FilterConfig, spans ...*model.Span) []*model.Span {
+	t.Helper()
+	processor, err := NewFilterProcessor("noise", config)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	in := make(chan *model.Span, len(spans))
+	out := processor.Process(ctx, in)
+	for _, span := range spans {
+		in <- span
+	}
+	close(in)
+
+	kept := make([]*model.Span, 0)
+	for span := range out {
+		kept = append(kept, span)
+	}
+	return kept
+}
+
+func TestFilterProcessorRules(t *testing.T) {
+	metrics := observability.NewMetrics()
+	config := DefaultFilterConfig()
+	config.Metrics = metrics
+	config.Rules = []FilterRule{
+		{Name: "healthcheck", OperationRegex: `^GET /(health|ready)$`},
+		{Name: "scrapes", Tags: map[string]string{"http.target": "/metrics"}},
+		{Name: "debug-service", Service: "debug"},
+		{Name: "tiny", MaxDuration: time.Millisecond, Expression: `kind == "internal"`},
+	}
+
+	spans := []*model.Span{
+		{OperationName: "GET /health"},
+		{OperationName: "GET /ready"},
+		{OperationName: "GET /metrics", Tags: []model.KeyValue{{Key: "http.target", VType: model.StringType, VStr: "/metrics"}}},
+		{OperationName: "work", Process: &model.Process{ServiceName: "debug"}},
+		{OperationName: "noop", Duration: time.Microsecond, Tags: []model.KeyValue{{Key: "span.kind", VType: model.StringType, VStr: "internal"}}},
+		{OperationName: "noop", Duration: time.Microsecond},
+		{OperationName: "GET /orders", Duration: 20 * time.Millisecond},
+	}
+
+	kept := runFilter(t, config, spans...)
+
+	require.Len(t, kept, 2)
+	assert.Equal(t, "noop", kept[0].OperationName)
+	assert.Equal(t, "GET /orders", kept[1].OperationName)
+
+	counters := metrics.Snapshot().Counters
+	assert.Equal(t, uint64(2), counters["filter.noise.dropped.healthcheck"])
+	assert.Equal(t, uint64(1), counters["filter.noise.dropped.scrapes"])
+	assert.Equal(t, uint64(1), counters["filter.noise.dropped.debug-service"])
+	assert.Equal(t, uint64(1), counters["filter.noise.dropped.tiny"])
+}
+
+func TestFilterProcessorDropTrace(t *testing.T) {
+	metrics := observability.NewMetrics()
+	config := DefaultFilterConfig()
+	config.Metrics = metrics
+	config.MaxTraces = 1
+	config.Rules = []FilterRule{
+		{Name: "synthetic", Tags: map[string]string{"synthetic": ""}, DropTrace: true},
+	}
+
+	synthetic := []model.KeyValue{{Key: "synthetic", VType: model.BoolType, VBool: true}}
+	kept := runFilter(t, config,
+		&model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 1, Tags: synthetic},
+		&model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 2},
+		&model.Span{TraceID: model.TraceID{Low: 2}, SpanID: 3, Tags: synthetic},
+		// Trace 1 was evicted by trace 2 (MaxTraces = 1)
+		&model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 4},
+		&model.Span{TraceID: model.TraceID{Low: 2}, SpanID: 5},
+	)
+
+	require.Len(t, kept, 1)
+	assert.Equal(t, model.SpanID(4), kept[0].SpanID)
+	assert.Equal(t, uint64(4), metrics.Snapshot().Counters["filter.noise.dropped.synthetic"])
+}
+
+func TestFilterProcessorInvalidRules(t *testing.T) {
+	tests := []FilterRule{
+		{Name: "empty"},
+		{Name: "regex", OperationRegex: "("},
+		{Name: "expr", Expression: "service =="},
+		{Name: "range", MinDuration: time.Second, MaxDuration: time.Millisecond},
+	}
+
+	for _, rule := range tests {
+		t.Run(rule.Name, func(t *testing.T) {
+			_, err := NewFilterProcessor("noise", FilterConfig{Rules: []FilterRule{rule}})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFilterProcessorAllocatesTraceMemoryLazily(t *testing.T) {
+	processor, err := NewFilterProcessor("noise", FilterConfig{Rules: []FilterRule{{Service: "debug"}}})
+	require.NoError(t, err)
+	assert.Nil(t, processor.traceOrder)
+
+	processor.rememberTrace(model.TraceID{Low: 1}, 0)
+	assert.Len(t, processor.traceOrder, DefaultFilterConfig().MaxTraces)
+}
+
+func TestTraceFilterProcessor(t *testing.T) {
+	metrics := observability.NewMetrics()
+	processor, err := NewTraceFilterProcessor("noise", FilterConfig{
+		Metrics: metrics,
+		Rules: []FilterRule{
+			{Name: "synthetic", Tags: map[string]string{"synthetic": ""}, DropTrace: true},
+			{Name: "healthcheck", OperationRegex: "^/health"},
+		},
+	})
+	require.NoError(t, err)
+
+	synthetic := []model.KeyValue{model.Bool("synthetic", true)}
+	newTrace := func(low uint64, spans ...*model.Span) *model.Trace {
+		trace := model.NewTrace(model.TraceID{Low: low})
+		for _, span := range spans {
+			span.TraceID = trace.TraceID
+			trace.AddSpan(span)
+		}
+		return trace
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	in := make(chan *model.Trace, 3)
+	out := processor.Process(ctx, in)
+	// The matching span arrives last, but the whole trace is dropped
+	in <- newTrace(1,
+		&model.Span{SpanID: 1, OperationName: "GET /cart"},
+		&model.Span{SpanID: 2, OperationName: "SELECT", Tags: synthetic})
+	in <- newTrace(2,
+		&model.Span{SpanID: 3, OperationName: "/health"},
+		&model.Span{SpanID: 4, OperationName: "GET /orders"})
+	in <- newTrace(3, &model.Span{SpanID: 5, OperationName: "/healthz"})
+	close(in)
+
+	var kept []*model.Trace
+	for trace := range out {
+		kept = append(kept, trace)
+	}
+	require.Len(t, kept, 1)
+	require.Len(t, kept[0].Spans, 1)
+	assert.Equal(t, model.SpanID(4), kept[0].Spans[0].SpanID)
+
+	counters := metrics.Snapshot().Counters
+	assert.Equal(t, uint64(2), counters["filter.noise.dropped.synthetic"])
+	assert.Equal(t, uint64(2), counters["filter.noise.dropped.healthcheck"])
+}
@@ -0,0 +1,150 @@
+package processor
+
+import (
+	"container/list"
+	"context"
+	"time"
+
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+// GroupByTraceProcessor buffers spans by TraceID and emits a complete
+// *model.Trace once no span of the trace has arrived for WaitDuration.
+// It is a pipeline.Connector from spans to traces.
+type GroupByTraceProcessor struct {
+	name         string
+	waitDuration time.Duration
+	maxTraces    int
+	metrics      *observability.Metrics
+	now          func() time.Time
+}
+
+// GroupByTraceConfig configures the group-by-trace processor
+type GroupByTraceConfig struct {
+	WaitDuration time.Duration          // Idle time after which a trace is complete
+	MaxTraces    int                    // Buffered traces; the least recently updated is released early
+	Metrics      *observability.Metrics // Optional; counts early releases
+}
+
+// DefaultGroupByTraceConfig returns default group-by-trace configuration
+func DefaultGroupByTraceConfig() GroupByTraceConfig {
+	return GroupByTraceConfig{
+		WaitDuration: 1 * time.Second,
+		MaxTraces:    100000,
+	}
+}
+
+// pendingTrace is a trace being assembled
+type pendingTrace struct {
+	trace    *model.Trace
+	lastSeen time.Time
+}
+
+// NewGroupByTraceProcessor creates a new group-by-trace processor
+func NewGroupByTraceProcessor(name string, config GroupByTraceConfig) *GroupByTraceProcessor {
+	defaults := DefaultGroupByTraceConfig()
+	if config.WaitDuration <= 0 {
+		config.WaitDuration = defaults.WaitDuration
+	}
+	if config.MaxTraces <= 0 {
+		config.MaxTraces = defaults.MaxTraces
+	}
+
+	return &GroupByTraceProcessor{
+		name:         name,
+		waitDuration: config.WaitDuration,
+		maxTraces:    config.MaxTraces,
+		metrics:      config.Metrics,
+		now:          time.Now,
+	}
+}
+
+// Process assembles spans into traces
+func (p *GroupByTraceProcessor) Process(ctx context.Context, in <-chan *model.Span) <-chan *model.Trace {
+	out := make(chan *model.Trace, 100)
+
+	go func() {
+		defer close(out)
+
+		// Traces ordered by last update, oldest first
+		order := list.New()
+		traces := make(map[model.TraceID]*list.Element)
+
+		release := func(elem *list.Element) bool {
+			pending := order.Remove(elem).(*pendingTrace)
+			delete(traces, pending.trace.TraceID)
+			select {
+			case out <- pending.trace:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		interval := p.waitDuration / 2
+		if interval < 10*time.Millisecond {
+			interval = 10 * time.Millisecond
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case span, ok := <-in:
+				if !ok {
+					// Input closed, release everything buffered
+					for order.Len() > 0 {
+						if !release(order.Front()) {
+							return
+						}
+					}
+					return
+				}
+
+				if elem, ok := traces[span.TraceID]; ok {
+					pending := elem.Value.(*pendingTrace)
+					pending.trace.AddSpan(span)
+					pending.lastSeen = p.now()
+					order.MoveToBack(elem)
+					continue
+				}
+
+				if order.Len() >= p.maxTraces {
+					if p.metrics != nil {
+						p.metrics.AddCounter("groupbytrace."+p.name+".released_early", 1)
+					}
+					if !release(order.Front()) {
+						return
+					}
+				}
+
+				trace := model.NewTrace(span.TraceID)
+				trace.AddSpan(span)
+				traces[span.TraceID] = order.PushBack(&pendingTrace{trace: trace, lastSeen: p.now()})
+
+			case <-ticker.C:
+				deadline := p.now().Add(-p.waitDuration)
+				for order.Len() > 0 {
+					front := order.Front()
+					if front.Value.(*pendingTrace).lastSeen.After(deadline) {
+						break
+					}
+					if !release(front) {
+						return
+					}
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Name returns the processor name
+func (p *GroupByTraceProcessor) Name() string {
+	return p.name
+}
@@ -0,0 +1,99 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+func TestGroupByTraceProcessorAssemblesTraces(t *testing.T) {
+	config := DefaultGroupByTraceConfig()
+	config.WaitDuration = 50 * time.Millisecond
+	processor := NewGroupByTraceProcessor("group", config)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	in := make(chan *model.Span, 10)
+	out := processor.Process(ctx, in)
+
+	frontend := []model.KeyValue{{Key: "hostname", VType: model.StringType, VStr: "web-1"}}
+	in <- &model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 1, Process: &model.Process{ServiceName: "frontend", Tags: frontend}}
+	in <- &model.Span{TraceID: model.TraceID{Low: 2}, SpanID: 3, Process: &model.Process{ServiceName: "batch"}}
+	in <- &model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 2, Process: &model.Process{ServiceName: "checkout"}}
+	in <- &model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 4, Process: &model.Process{ServiceName: "frontend", Tags: frontend}}
+
+	// Idle traces are released without closing the input
+	traces := map[uint64]*model.Trace{}
+	for len(traces) < 2 {
+		select {
+		case trace := <-out:
+			traces[trace.TraceID.Low] = trace
+		case <-ctx.Done():
+			t.Fatal("traces were not released")
+		}
+	}
+	close(in)
+
+	trace := traces[1]
+	require.Len(t, trace.Spans, 3)
+	require.Len(t, trace.Processes, 2)
+	assert.Equal(t, "p1", trace.Spans[0].ProcessID)
+	assert.Equal(t, "p2", trace.Spans[1].ProcessID)
+	assert.Equal(t, "p1", trace.Spans[2].ProcessID)
+	assert.Same(t, trace.Spans[0].Process, trace.Spans[2].Process)
+
+	assert.Len(t, traces[2].Spans, 1)
+}
+
+func TestGroupByTraceProcessorFlushOnClose(t *testing.T) {
+	config := DefaultGroupByTraceConfig()
+	config.WaitDuration = time.Hour
+	processor := NewGroupByTraceProcessor("group", config)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	in := make(chan *model.Span, 2)
+	out := processor.Process(ctx, in)
+	in <- &model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 1}
+	in <- &model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 2}
+	close(in)
+
+	traces := make([]*model.Trace, 0)
+	for trace := range out {
+		traces = append(traces, trace)
+	}
+	require.Len(t, traces, 1)
+	assert.Len(t, traces[0].Spans, 2)
+}
+
+func TestGroupByTraceProcessorMaxTraces(t *testing.T) {
+	metrics := observability.NewMetrics()
+	config := DefaultGroupByTraceConfig()
+	config.WaitDuration = time.Hour
+	config.MaxTraces = 2
+	config.Metrics = metrics
+	processor := NewGroupByTraceProcessor("group", config)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	in := make(chan *model.Span, 4)
+	out := processor.Process(ctx, in)
+	in <- &model.Span{TraceID: model.TraceID{Low: 1}}
+	in <- &model.Span{TraceID: model.TraceID{Low: 2}}
+	in <- &model.Span{TraceID: model.TraceID{Low: 1}} // Trace 2 is now least recently updated
+	in <- &model.Span{TraceID: model.TraceID{Low: 3}}
+
+	// Trace 2 is released early to make room
+	first := <-out
+	assert.Equal(t, uint64(2), first.TraceID.Low)
+	assert.Equal(t, uint64(1), metrics.Snapshot().Counters["groupbytrace.group.released_early"])
+	close(in)
+}
@@ -0,0 +1,164 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/vjranagit/jaeger-toolkit/pkg/deployment"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+// PodLookup resolves a pod by IP; implemented by deployment.PodInformer
+type PodLookup interface {
+	PodByIP(ip string) (deployment.Pod, bool)
+}
+
+// K8sAttributesProcessor tags each span's process with the metadata of
+// the Kubernetes pod that sent it. The sender is identified by its IP,
+// which the receiver records from the connection (see
+// model.TagPeerIP).
+type K8sAttributesProcessor struct {
+	name      string
+	pods      PodLookup
+	ipTags    []string
+	labels    map[string]bool
+	allLabels bool
+	metrics   *observability.Metrics
+}
+
+// K8sAttributesConfig configures the Kubernetes attributes processor
+type K8sAttributesConfig struct {
+	Pods    PodLookup              // Pod cache, e.g. a running deployment.PodInformer
+	IPTags  []string               // Span, then process, tags holding the sender IP, in order of preference
+	Labels  []string               // Pod labels copied as k8s.pod.label.<key> (nil = all)
+	Metrics *observability.Metrics // Optional; counts spans without a matching pod
+}
+
+// DefaultK8sAttributesConfig returns default Kubernetes attributes configuration
+func DefaultK8sAttributesConfig() K8sAttributesConfig {
+	return K8sAttributesConfig{
+		IPTags: []string{model.TagPeerIP, "k8s.pod.ip", "ip"},
+	}
+}
+
+// NewK8sAttributesProcessor creates a new Kubernetes attributes processor
+func NewK8sAttributesProcessor(name string, config K8sAttributesConfig) (*K8sAttributesProcessor, error) {
+	if config.Pods == nil {
+		return nil, fmt.Errorf("pod lookup is required")
+	}
+	if len(config.IPTags) == 0 {
+		config.IPTags = DefaultK8sAttributesConfig().IPTags
+	}
+
+	return &K8sAttributesProcessor{
+		name:      name,
+		pods:      config.Pods,
+		ipTags:    config.IPTags,
+		labels:    toSet(config.Labels),
+		allLabels: config.Labels == nil,
+		metrics:   config.Metrics,
+	}, nil
+}
+
+// Process enriches each span
+func (p *K8sAttributesProcessor) Process(ctx context.Context, in <-chan *model.Span) <-chan *model.Span {
+	out := make(chan *model.Span, 100)
+
+	go func() {
+		defer close(out)
+
+		for {
+			select {
+			case span, ok := <-in:
+				if !ok {
+					return
+				}
+
+				p.enrich(span)
+
+				select {
+				case out <- span:
+				case <-ctx.Done():
+					return
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// enrich adds the pod metadata of the span's sender
+func (p *K8sAttributesProcessor) enrich(span *model.Span) {
+	pod, ok := p.pods.PodByIP(p.senderIP(span))
+	if !ok {
+		if p.metrics != nil {
+			p.metrics.AddCounter("k8sattributes."+p.name+".unmatched", 1)
+		}
+		return
+	}
+
+	if span.Process == nil {
+		span.Process = &model.Process{}
+	}
+	add := func(key, value string) {
+		if value != "" && findTag(span.Process.Tags, key) < 0 {
+			span.Process.Tags = append(span.Process.Tags, model.KeyValue{Key: key, VType: model.StringType, VStr: value})
+		}
+	}
+
+	add("k8s.pod.name", pod.Name)
+	add("k8s.pod.uid", pod.UID)
+	add("k8s.namespace.name", pod.Namespace)
+	add("k8s.node.name", pod.NodeName)
+	add("k8s.deployment.name", pod.Deployment)
+	keys := make([]string, 0, len(pod.Labels))
+	for key := range pod.Labels {
+		if p.allLabels || p.labels[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		add("k8s.pod.label."+key, pod.Labels[key])
+	}
+}
+
+// senderIP returns the first IP found in the configured tags
+func (p *K8sAttributesProcessor) senderIP(span *model.Span) string {
+	for _, key := range p.ipTags {
+		if idx := findTag(span.Tags, key); idx >= 0 {
+			return hostOnly(span.Tags[idx].VStr)
+		}
+		if span.Process != nil {
+			if idx := findTag(span.Process.Tags, key); idx >= 0 {
+				return hostOnly(span.Process.Tags[idx].VStr)
+			}
+		}
+	}
+	return ""
+}
+
+// hostOnly strips a port from an address
+func hostOnly(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// Name returns the processor name
+func (p *K8sAttributesProcessor) Name() string {
+	return p.name
+}
+
+// MutatesData reports that the processor modifies spans in place
+func (p *K8sAttributesProcessor) MutatesData() bool {
+	return true
+}
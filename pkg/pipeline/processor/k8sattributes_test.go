@@ -0,0 +1,76 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vjranagit/jaeger-toolkit/pkg/deployment"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+// fakePods is an informer stand-in
+type fakePods map[string]deployment.Pod
+
+func (f fakePods) PodByIP(ip string) (deployment.Pod, bool) {
+	pod, ok := f[ip]
+	return pod, ok
+}
+
+func TestK8sAttributesProcessor(t *testing.T) {
+	metrics := observability.NewMetrics()
+	config := DefaultK8sAttributesConfig()
+	config.Pods = fakePods{
+		"10.1.0.5": {
+			Name:       "checkout-7d9f8-abcde",
+			Namespace:  "shop",
+			UID:        "uid-1",
+			NodeName:   "node-1",
+			Deployment: "checkout",
+			Labels:     map[string]string{"app": "checkout", "pod-template-hash": "7d9f8"},
+		},
+	}
+	config.Labels = []string{"app"}
+	config.Metrics = metrics
+	processor, err := NewK8sAttributesProcessor("k8s", config)
+	require.NoError(t, err)
+
+	matched := &model.Span{
+		Tags:    []model.KeyValue{stringTag("jaeger-toolkit.peer.ip", "10.1.0.5:43122")},
+		Process: &model.Process{ServiceName: "checkout", Tags: []model.KeyValue{stringTag("k8s.namespace.name", "sdk-reported")}},
+	}
+	fromProcess := &model.Span{Process: &model.Process{Tags: []model.KeyValue{stringTag("ip", "10.1.0.5")}}}
+	unknown := &model.Span{Tags: []model.KeyValue{stringTag("jaeger-toolkit.peer.ip", "10.9.9.9")}}
+
+	in := make(chan *model.Span, 3)
+	in <- matched
+	in <- fromProcess
+	in <- unknown
+	close(in)
+	for range processor.Process(context.Background(), in) {
+	}
+
+	values := map[string]string{}
+	for _, kv := range matched.Process.Tags {
+		values[kv.Key] = kv.VStr
+	}
+	assert.Equal(t, map[string]string{
+		"k8s.pod.name":        "checkout-7d9f8-abcde",
+		"k8s.pod.uid":         "uid-1",
+		"k8s.namespace.name":  "sdk-reported", // Existing values are kept
+		"k8s.node.name":       "node-1",
+		"k8s.deployment.name": "checkout",
+		"k8s.pod.label.app":   "checkout",
+	}, values)
+
+	assert.GreaterOrEqual(t, findTag(fromProcess.Process.Tags, "k8s.pod.name"), 0)
+	assert.Nil(t, unknown.Process)
+	assert.Equal(t, uint64(1), metrics.Snapshot().Counters["k8sattributes.k8s.unmatched"])
+}
+
+func TestNewK8sAttributesProcessorRequiresPods(t *testing.T) {
+	_, err := NewK8sAttributesProcessor("k8s", DefaultK8sAttributesConfig())
+	assert.Error(t, err)
+}
@@ -0,0 +1,218 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+// LimitsProcessor protects downstream components from pathological
+// payloads by capping the size of spans and traces. Truncated content is
+// noted in Span.Warnings; spans beyond MaxSpansPerTrace are dropped, with
+// a warning on the last span passed. A zero limit disables that check.
+type LimitsProcessor struct {
+	name    string
+	config  LimitsConfig
+	metrics *observability.Metrics
+
+	// Spans passed per trace and services seen in counter names; only
+	// touched by the processing goroutine
+	traceSpans *fifoMap[model.TraceID, int]
+	services   map[string]bool
+}
+
+// LimitsConfig configures the limits processor
+type LimitsConfig struct {
+	MaxTags          int                    // Tags per span
+	MaxLogs          int                    // Logs per span
+	MaxStringLength  int                    // Bytes per string value in tags and log fields
+	MaxBinaryLength  int                    // Bytes per binary value in tags and log fields
+	MaxSpansPerTrace int                    // Spans passed per trace; the rest are dropped
+	MaxTraces        int                    // Traces tracked for MaxSpansPerTrace
+	MaxServices      int                    // Services with their own counters; the rest share OverflowValue
+	Metrics          *observability.Metrics // Optional; receives per-service counters
+}
+
+// DefaultLimitsConfig returns default limits configuration
+func DefaultLimitsConfig() LimitsConfig {
+	return LimitsConfig{
+		MaxTags:          128,
+		MaxLogs:          128,
+		MaxStringLength:  4096,
+		MaxBinaryLength:  4096,
+		MaxSpansPerTrace: 10000,
+		MaxTraces:        100000,
+		MaxServices:      1000,
+	}
+}
+
+// NewLimitsProcessor creates a new limits processor
+func NewLimitsProcessor(name string, config LimitsConfig) *LimitsProcessor {
+	defaults := DefaultLimitsConfig()
+	if config.MaxTraces <= 0 {
+		config.MaxTraces = defaults.MaxTraces
+	}
+	if config.MaxServices <= 0 {
+		config.MaxServices = defaults.MaxServices
+	}
+
+	return &LimitsProcessor{
+		name:       name,
+		config:     config,
+		metrics:    config.Metrics,
+		traceSpans: newFIFOMap[model.TraceID, int](config.MaxTraces),
+		services:   make(map[string]bool),
+	}
+}
+
+// Process enforces the limits on each span
+func (p *LimitsProcessor) Process(ctx context.Context, in <-chan *model.Span) <-chan *model.Span {
+	out := make(chan *model.Span, 100)
+
+	go func() {
+		defer close(out)
+
+		for {
+			select {
+			case span, ok := <-in:
+				if !ok {
+					return
+				}
+
+				if !p.admit(span) {
+					continue
+				}
+				p.truncate(span)
+
+				select {
+				case out <- span:
+				case <-ctx.Done():
+					return
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// admit counts a span against its trace and reports whether it fits
+func (p *LimitsProcessor) admit(span *model.Span) bool {
+	if p.config.MaxSpansPerTrace <= 0 {
+		return true
+	}
+	count, _ := p.traceSpans.Get(span.TraceID)
+	if count >= p.config.MaxSpansPerTrace {
+		p.count(span, "dropped_spans", 1)
+		return false
+	}
+	p.traceSpans.Put(span.TraceID, count+1)
+	if max := p.config.MaxSpansPerTrace; count+1 == max {
+		// Later spans are dropped; this is the last one to carry a note
+		span.Warnings = append(span.Warnings, fmt.Sprintf("limit of %d spans per trace reached: further spans of the trace are dropped", max))
+	}
+	return true
+}
+
+// truncate caps tags, logs and values of a span. What is kept is
+// copied, so the oversized originals can be freed.
+func (p *LimitsProcessor) truncate(span *model.Span) {
+	if max := p.config.MaxTags; max > 0 && len(span.Tags) > max {
+		dropped := len(span.Tags) - max
+		span.Tags = append([]model.KeyValue(nil), span.Tags[:max]...)
+		span.Warnings = append(span.Warnings, fmt.Sprintf("%d tags dropped: limit of %d tags per span exceeded", dropped, max))
+		p.count(span, "dropped_tags", dropped)
+	}
+
+	if max := p.config.MaxLogs; max > 0 && len(span.Logs) > max {
+		dropped := len(span.Logs) - max
+		span.Logs = append([]model.Log(nil), span.Logs[:max]...)
+		span.Warnings = append(span.Warnings, fmt.Sprintf("%d logs dropped: limit of %d logs per span exceeded", dropped, max))
+		p.count(span, "dropped_logs", dropped)
+	}
+
+	var truncatedStrings, truncatedBinaries int
+	truncateValues := func(values []model.KeyValue) {
+		for i := range values {
+			kv := &values[i]
+			switch kv.VType {
+			case model.StringType:
+				if max := p.config.MaxStringLength; max > 0 && len(kv.VStr) > max {
+					kv.VStr = strings.Clone(truncateUTF8(kv.VStr, max))
+					truncatedStrings++
+				}
+			case model.BinaryType:
+				if max := p.config.MaxBinaryLength; max > 0 && len(kv.VBinary) > max {
+					kv.VBinary = bytes.Clone(kv.VBinary[:max])
+					truncatedBinaries++
+				}
+			}
+		}
+	}
+	truncateValues(span.Tags)
+	for i := range span.Logs {
+		truncateValues(span.Logs[i].Fields)
+	}
+	if span.Process != nil {
+		truncateValues(span.Process.Tags)
+	}
+
+	if truncatedStrings > 0 {
+		span.Warnings = append(span.Warnings, fmt.Sprintf("%d string values truncated to %d bytes", truncatedStrings, p.config.MaxStringLength))
+		p.count(span, "truncated_strings", truncatedStrings)
+	}
+	if truncatedBinaries > 0 {
+		span.Warnings = append(span.Warnings, fmt.Sprintf("%d binary values truncated to %d bytes", truncatedBinaries, p.config.MaxBinaryLength))
+		p.count(span, "truncated_binaries", truncatedBinaries)
+	}
+}
+
+// truncateUTF8 shortens s to at most max bytes without splitting a rune
+func truncateUTF8(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
+
+// count adds to a per-service counter if metrics are enabled. Service
+// names come from untrusted input, so only the first MaxServices get
+// their own counters.
+func (p *LimitsProcessor) count(span *model.Span, what string, n int) {
+	if p.metrics == nil {
+		return
+	}
+	service := ""
+	if span.Process != nil {
+		service = span.Process.ServiceName
+	}
+	if !p.services[service] {
+		if len(p.services) >= p.config.MaxServices {
+			service = OverflowValue
+		} else {
+			p.services[service] = true
+		}
+	}
+	p.metrics.AddCounter("limits."+p.name+"."+what+"."+service, uint64(n))
+}
+
+// Name returns the processor name
+func (p *LimitsProcessor) Name() string {
+	return p.name
+}
+
+// MutatesData reports that the processor modifies spans in place
+func (p *LimitsProcessor) MutatesData() bool {
+	return true
+}
@@ -0,0 +1,132 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+func TestLimitsProcessorTruncatesSpans(t *testing.T) {
+	metrics := observability.NewMetrics()
+	processor := NewLimitsProcessor("limits", LimitsConfig{
+		MaxTags:         2,
+		MaxLogs:         1,
+		MaxStringLength: 5,
+		MaxBinaryLength: 3,
+		Metrics:         metrics,
+	})
+
+	payload := []byte{1, 2, 3, 4, 5}
+	tags := []model.KeyValue{
+		stringTag("short", "ok"),
+		stringTag("long", "héllo world"),
+		stringTag("extra", "dropped"),
+	}
+	logs := []model.Log{
+		{Fields: []model.KeyValue{{Key: "payload", VType: model.BinaryType, VBinary: payload}}},
+		{Fields: []model.KeyValue{stringTag("event", "dropped")}},
+	}
+	span := &model.Span{
+		Process: &model.Process{ServiceName: "noisy", Tags: []model.KeyValue{stringTag("host.name", "web-123456")}},
+		Tags:    tags,
+		Logs:    logs,
+	}
+
+	in := make(chan *model.Span, 1)
+	in <- span
+	close(in)
+	for range processor.Process(context.Background(), in) {
+	}
+
+	require.Len(t, span.Tags, 2)
+	assert.Equal(t, "ok", span.Tags[0].VStr)
+	assert.Equal(t, "héll", span.Tags[1].VStr) // Not split inside "é"
+	require.Len(t, span.Logs, 1)
+	assert.Equal(t, []byte{1, 2, 3}, span.Logs[0].Fields[0].VBinary)
+	assert.Equal(t, "web-1", span.Process.Tags[0].VStr)
+
+	// The kept parts are copies, so the originals can be freed
+	assert.Equal(t, 2, cap(span.Tags))
+	assert.Equal(t, 1, cap(span.Logs))
+	span.Tags[0].VStr = "changed"
+	span.Logs[0].Timestamp = time.Unix(1, 0)
+	span.Logs[0].Fields[0].VBinary[0] = 9
+	assert.Equal(t, "ok", tags[0].VStr)
+	assert.True(t, logs[0].Timestamp.IsZero())
+	assert.Equal(t, byte(1), payload[0])
+
+	assert.Equal(t, []string{
+		"1 tags dropped: limit of 2 tags per span exceeded",
+		"1 logs dropped: limit of 1 logs per span exceeded",
+		"2 string values truncated to 5 bytes",
+		"1 binary values truncated to 3 bytes",
+	}, span.Warnings)
+
+	counters := metrics.Snapshot().Counters
+	assert.Equal(t, uint64(1), counters["limits.limits.dropped_tags.noisy"])
+	assert.Equal(t, uint64(1), counters["limits.limits.dropped_logs.noisy"])
+	assert.Equal(t, uint64(2), counters["limits.limits.truncated_strings.noisy"])
+	assert.Equal(t, uint64(1), counters["limits.limits.truncated_binaries.noisy"])
+}
+
+func TestLimitsProcessorSpansPerTrace(t *testing.T) {
+	metrics := observability.NewMetrics()
+	processor := NewLimitsProcessor("limits", LimitsConfig{MaxSpansPerTrace: 2, Metrics: metrics})
+
+	in := make(chan *model.Span, 10)
+	for i := 1; i <= 4; i++ {
+		in <- &model.Span{TraceID: model.TraceID{Low: 1}, SpanID: model.SpanID(i), Process: &model.Process{ServiceName: "fanout"}}
+	}
+	in <- &model.Span{TraceID: model.TraceID{Low: 2}, SpanID: 1, Tags: []model.KeyValue{stringTag("k", strings.Repeat("x", 10))}}
+	close(in)
+
+	var spans []*model.Span
+	for span := range processor.Process(context.Background(), in) {
+		spans = append(spans, span)
+	}
+
+	require.Len(t, spans, 3)
+	assert.Equal(t, model.SpanID(1), spans[0].SpanID)
+	assert.Empty(t, spans[0].Warnings)
+	// The last span passed notes the drops that follow
+	assert.Equal(t, model.SpanID(2), spans[1].SpanID)
+	assert.Equal(t, []string{"limit of 2 spans per trace reached: further spans of the trace are dropped"}, spans[1].Warnings)
+	assert.Equal(t, model.SpanID(1), spans[2].SpanID)
+	assert.Empty(t, spans[2].Warnings)
+	assert.Equal(t, uint64(2), metrics.Snapshot().Counters["limits.limits.dropped_spans.fanout"])
+}
+
+func TestLimitsProcessorBoundsServiceCounters(t *testing.T) {
+	metrics := observability.NewMetrics()
+	processor := NewLimitsProcessor("limits", LimitsConfig{MaxTags: 1, MaxServices: 2, Metrics: metrics})
+
+	in := make(chan *model.Span, 4)
+	for _, service := range []string{"a", "b", "c", "d"} {
+		in <- &model.Span{
+			Process: &model.Process{ServiceName: service},
+			Tags:    []model.KeyValue{stringTag("k1", "v"), stringTag("k2", "v")},
+		}
+	}
+	close(in)
+	for range processor.Process(context.Background(), in) {
+	}
+
+	counters := metrics.Snapshot().Counters
+	assert.Equal(t, uint64(1), counters["limits.limits.dropped_tags.a"])
+	assert.Equal(t, uint64(1), counters["limits.limits.dropped_tags.b"])
+	assert.Equal(t, uint64(2), counters["limits.limits.dropped_tags."+OverflowValue])
+	assert.NotContains(t, counters, "limits.limits.dropped_tags.c")
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	assert.Equal(t, "abc", truncateUTF8("abc", 5))
+	assert.Equal(t, "ab", truncateUTF8("abc", 2))
+	assert.Equal(t, "", truncateUTF8("日本", 2))
+	assert.Equal(t, "日", truncateUTF8("日本", 4))
+}
@@ -0,0 +1,379 @@
+package processor
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+// RedactionProcessor masks or hashes PII in span tags, log fields and
+// process tags, and optionally drops keys outside an allow-list
+type RedactionProcessor struct {
+	name        string
+	mode        RedactionMode
+	allowed     map[string]bool
+	ignored     map[string]bool
+	hashKey     []byte
+	detectors   []detector
+	summaryTags bool
+	metrics     *observability.Metrics
+}
+
+// RedactionMode specifies how matches are replaced
+type RedactionMode string
+
+const (
+	// RedactMask replaces matches with a fixed mask
+	RedactMask RedactionMode = "mask"
+	// RedactHash replaces matches with their hex-encoded HMAC-SHA256,
+	// keyed with HashKey
+	RedactHash RedactionMode = "hash"
+)
+
+// Mask written in place of redacted values
+const RedactionMask = "****"
+
+// Summary tags recorded on spans that were redacted
+const (
+	RedactedKeysTagKey  = "redaction.redacted.keys"
+	RedactedCountTagKey = "redaction.redacted.count"
+	DroppedKeysTagKey   = "redaction.dropped.keys"
+	DroppedCountTagKey  = "redaction.dropped.count"
+)
+
+// RedactionConfig configures the redaction processor
+type RedactionConfig struct {
+	Mode           RedactionMode
+	HashKey        string   // HMAC key for RedactHash; required in that mode
+	AllowedKeys    []string // If set, span tags and log fields outside the list are dropped
+	IgnoredKeys    []string // Never scanned or dropped
+	CreditCards    bool     // Luhn-checked card numbers
+	Emails         bool
+	JWTs           bool
+	IPAddresses    bool     // IPv4 and IPv6
+	CustomPatterns []string // Additional regexes
+	SummaryTags    bool     // Record redacted/dropped keys on the span
+	Metrics        *observability.Metrics
+}
+
+// DefaultRedactionConfig returns a configuration with all detectors enabled
+func DefaultRedactionConfig() RedactionConfig {
+	return RedactionConfig{
+		Mode:        RedactMask,
+		CreditCards: true,
+		Emails:      true,
+		JWTs:        true,
+		IPAddresses: true,
+		SummaryTags: true,
+	}
+}
+
+// detector finds PII candidates; verify rejects false positives
+type detector struct {
+	pattern *regexp.Regexp
+	verify  func(string) bool
+}
+
+var (
+	creditCardPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	emailPattern      = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	jwtPattern        = regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`)
+	ipv4Pattern       = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	ipv6Pattern       = regexp.MustCompile(`[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}`)
+)
+
+// NewRedactionProcessor creates a new redaction processor
+func NewRedactionProcessor(name string, config RedactionConfig) (*RedactionProcessor, error) {
+	switch config.Mode {
+	case "":
+		config.Mode = RedactMask
+	case RedactMask:
+	case RedactHash:
+		// Unkeyed hashes of card numbers or IPv4 addresses can be
+		// reversed by enumerating the small keyspace
+		if config.HashKey == "" {
+			return nil, fmt.Errorf("redaction mode %q requires a hash key", config.Mode)
+		}
+	default:
+		return nil, fmt.Errorf("unknown redaction mode %q", config.Mode)
+	}
+
+	detectors := make([]detector, 0)
+	if config.CreditCards {
+		detectors = append(detectors, detector{pattern: creditCardPattern, verify: luhnValid})
+	}
+	if config.Emails {
+		detectors = append(detectors, detector{pattern: emailPattern})
+	}
+	if config.JWTs {
+		detectors = append(detectors, detector{pattern: jwtPattern})
+	}
+	if config.IPAddresses {
+		detectors = append(detectors,
+			detector{pattern: ipv4Pattern, verify: validIP},
+			detector{pattern: ipv6Pattern, verify: validIP},
+		)
+	}
+	for _, pattern := range config.CustomPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid custom pattern %q: %w", pattern, err)
+		}
+		detectors = append(detectors, detector{pattern: re})
+	}
+
+	var allowed map[string]bool
+	if len(config.AllowedKeys) > 0 {
+		allowed = toSet(config.AllowedKeys)
+	}
+
+	return &RedactionProcessor{
+		name:        name,
+		mode:        config.Mode,
+		allowed:     allowed,
+		ignored:     toSet(config.IgnoredKeys),
+		hashKey:     []byte(config.HashKey),
+		detectors:   detectors,
+		summaryTags: config.SummaryTags,
+		metrics:     config.Metrics,
+	}, nil
+}
+
+// Process redacts spans
+func (p *RedactionProcessor) Process(ctx context.Context, in <-chan *model.Span) <-chan *model.Span {
+	out := make(chan *model.Span)
+
+	go func() {
+		defer close(out)
+
+		for {
+			select {
+			case span, ok := <-in:
+				if !ok {
+					return
+				}
+
+				p.redactSpan(span)
+
+				select {
+				case out <- span:
+				case <-ctx.Done():
+					return
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// redactionSummary collects the keys touched on a span
+type redactionSummary struct {
+	redacted []string
+	dropped  []string
+}
+
+// redactSpan redacts a span in place
+func (p *RedactionProcessor) redactSpan(span *model.Span) {
+	summary := &redactionSummary{}
+
+	if p.summaryTags {
+		// Summary tags sent by the client or left by an earlier run
+		// would be read in place of this run's
+		span.Tags = removeSummaryTags(span.Tags)
+	}
+	span.Tags = p.redactTags(span.Tags, "", true, summary)
+	for i := range span.Logs {
+		span.Logs[i].Fields = p.redactTags(span.Logs[i].Fields, "logs.", true, summary)
+	}
+	// Process tags identify the sender; they are scanned but never dropped
+	if span.Process != nil {
+		span.Process.Tags = p.redactTags(span.Process.Tags, "process.", false, summary)
+	}
+
+	if p.metrics != nil {
+		if n := len(summary.redacted); n > 0 {
+			p.metrics.AddCounter("redaction."+p.name+".redacted", uint64(n))
+		}
+		if n := len(summary.dropped); n > 0 {
+			p.metrics.AddCounter("redaction."+p.name+".dropped", uint64(n))
+		}
+	}
+
+	if p.summaryTags {
+		if len(summary.redacted) > 0 {
+			span.Tags = append(span.Tags, summaryTags(RedactedKeysTagKey, RedactedCountTagKey, summary.redacted)...)
+		}
+		if len(summary.dropped) > 0 {
+			span.Tags = append(span.Tags, summaryTags(DroppedKeysTagKey, DroppedCountTagKey, summary.dropped)...)
+		}
+	}
+}
+
+// redactTags filters and scans a tag list, recording touched keys with prefix
+func (p *RedactionProcessor) redactTags(tags []model.KeyValue, prefix string, allowList bool, summary *redactionSummary) []model.KeyValue {
+	kept := tags[:0]
+	for _, tag := range tags {
+		if p.ignored[tag.Key] {
+			kept = append(kept, tag)
+			continue
+		}
+		if allowList && p.allowed != nil && !p.allowed[tag.Key] {
+			summary.dropped = append(summary.dropped, prefix+tag.Key)
+			continue
+		}
+		switch tag.VType {
+		case model.StringType:
+			if redacted, changed := p.redactString(tag.VStr); changed {
+				tag.VStr = redacted
+				summary.redacted = append(summary.redacted, prefix+tag.Key)
+			}
+		case model.Int64Type, model.BinaryType:
+			// Numbers and bytes cannot be partially masked: a match
+			// replaces the value with a string tag
+			original := []byte(tag.AsString())
+			if tag.VType == model.BinaryType {
+				original = tag.VBinary
+			}
+			if p.detect(string(original)) || p.detect(tag.AsString()) {
+				tag = model.String(tag.Key, p.replacement(original))
+				summary.redacted = append(summary.redacted, prefix+tag.Key)
+			}
+		}
+		kept = append(kept, tag)
+	}
+	return kept
+}
+
+// redactString replaces every detected match in value
+func (p *RedactionProcessor) redactString(value string) (string, bool) {
+	changed := false
+	for _, d := range p.detectors {
+		value = d.pattern.ReplaceAllStringFunc(value, func(match string) string {
+			if d.verify != nil && !d.verify(match) {
+				return match
+			}
+			changed = true
+			return p.replacement([]byte(match))
+		})
+	}
+	return value, changed
+}
+
+// detect reports whether any detector matches in value
+func (p *RedactionProcessor) detect(value string) bool {
+	for _, d := range p.detectors {
+		for _, match := range d.pattern.FindAllString(value, -1) {
+			if d.verify == nil || d.verify(match) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// replacement returns the masked or hashed form of a match
+func (p *RedactionProcessor) replacement(match []byte) string {
+	if p.mode == RedactHash {
+		mac := hmac.New(sha256.New, p.hashKey)
+		mac.Write(match)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	return RedactionMask
+}
+
+// removeSummaryTags removes the summary tags from a tag list
+func removeSummaryTags(tags []model.KeyValue) []model.KeyValue {
+	kept := tags[:0]
+	for _, tag := range tags {
+		switch tag.Key {
+		case RedactedKeysTagKey, RedactedCountTagKey, DroppedKeysTagKey, DroppedCountTagKey:
+		default:
+			kept = append(kept, tag)
+		}
+	}
+	return kept
+}
+
+// summaryTags builds the keys/count summary pair
+func summaryTags(keysTag, countTag string, keys []string) []model.KeyValue {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	return []model.KeyValue{
+		{Key: keysTag, VType: model.StringType, VStr: strings.Join(sorted, ",")},
+		{Key: countTag, VType: model.Int64Type, VInt64: int64(len(sorted))},
+	}
+}
+
+// luhnValid checks a candidate card number with the Luhn algorithm
+func luhnValid(candidate string) bool {
+	sum, digits := 0, 0
+	double := false
+	for i := len(candidate) - 1; i >= 0; i-- {
+		c := candidate[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+		digits++
+	}
+	return digits >= 13 && digits <= 19 && sum%10 == 0
+}
+
+// validIP rejects regex candidates that do not parse as an address.
+// IPv6 candidates need at least two non-empty groups so that scope
+// operators such as "Foo::Bar" are not mistaken for addresses.
+func validIP(candidate string) bool {
+	if net.ParseIP(candidate) == nil {
+		return false
+	}
+	if !strings.Contains(candidate, ":") {
+		return true
+	}
+	groups := 0
+	for _, group := range strings.Split(candidate, ":") {
+		if group != "" {
+			groups++
+		}
+	}
+	return groups >= 2
+}
+
+// toSet builds a lookup set from a list
+func toSet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+	return set
+}
+
+// Name returns the processor name
+func (p *RedactionProcessor) Name() string {
+	return p.name
+}
+
+// MutatesData reports that the processor modifies spans in place
+func (p *RedactionProcessor) MutatesData() bool {
+	return true
+}
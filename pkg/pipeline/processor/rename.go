@@ -0,0 +1,251 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/vjranagit/jaeger-toolkit/pkg/filter"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+// RenameProcessor rewrites OperationName to keep its cardinality low.
+// For each span the first rule matching its service is applied, in three
+// steps:
+//
+//  1. Template renders a new name from tags, e.g. "{http.method} {http.route}"
+//  2. Extract patterns move named groups into tags, leaving "{group}" in the name
+//  3. NormalizePaths replaces ID-like path segments with placeholders
+type RenameProcessor struct {
+	name    string
+	rules   []renameRule
+	match   *filter.Matcher
+	metrics *observability.Metrics
+}
+
+// RenameRule describes how to rename the operations of a service
+type RenameRule struct {
+	Service        string   // Exact Process.ServiceName ("" = any service)
+	Template       string   // "{tag}" placeholders; skipped if a referenced tag is missing
+	Extract        []string // Regexes with named groups applied to the name
+	NormalizePaths bool     // Replace numeric, UUID and hex path segments and drop query strings
+}
+
+// RenameConfig configures the rename processor
+type RenameConfig struct {
+	Rules   []RenameRule
+	Match   *filter.Matcher        // Optional include/exclude; other spans pass unchanged
+	Metrics *observability.Metrics // Optional; counts renamed spans
+}
+
+// Placeholders for normalized path segments
+const (
+	PlaceholderID   = "{id}"
+	PlaceholderUUID = "{uuid}"
+	PlaceholderHex  = "{hex}"
+)
+
+var (
+	numericSegment = regexp.MustCompile(`^[0-9]+$`)
+	uuidSegment    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hexSegment     = regexp.MustCompile(`^[0-9a-fA-F]{8,}$`)
+	templateField  = regexp.MustCompile(`\{([^{}]+)\}`)
+)
+
+// renameRule is a RenameRule with its template and patterns compiled
+type renameRule struct {
+	RenameRule
+	literals []string // Template text around the fields; one more than fields
+	fields   []string // Tag keys referenced by the template
+	extract  []*regexp.Regexp
+}
+
+// NewRenameProcessor creates a new rename processor
+func NewRenameProcessor(name string, config RenameConfig) (*RenameProcessor, error) {
+	rules := make([]renameRule, 0, len(config.Rules))
+	for i, rule := range config.Rules {
+		if rule.Template == "" && len(rule.Extract) == 0 && !rule.NormalizePaths {
+			return nil, fmt.Errorf("rule %d: nothing to do", i)
+		}
+		compiled := renameRule{RenameRule: rule}
+
+		if rule.Template != "" {
+			last := 0
+			for _, loc := range templateField.FindAllStringSubmatchIndex(rule.Template, -1) {
+				compiled.literals = append(compiled.literals, rule.Template[last:loc[0]])
+				compiled.fields = append(compiled.fields, rule.Template[loc[2]:loc[3]])
+				last = loc[1]
+			}
+			compiled.literals = append(compiled.literals, rule.Template[last:])
+		}
+
+		for _, pattern := range rule.Extract {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: invalid extract pattern: %w", i, err)
+			}
+			named := false
+			for _, group := range re.SubexpNames() {
+				named = named || group != ""
+			}
+			if !named {
+				return nil, fmt.Errorf("rule %d: extract pattern %q has no named groups", i, pattern)
+			}
+			compiled.extract = append(compiled.extract, re)
+		}
+
+		rules = append(rules, compiled)
+	}
+
+	return &RenameProcessor{
+		name:    name,
+		rules:   rules,
+		match:   config.Match,
+		metrics: config.Metrics,
+	}, nil
+}
+
+// Process renames matching spans
+func (p *RenameProcessor) Process(ctx context.Context, in <-chan *model.Span) <-chan *model.Span {
+	out := make(chan *model.Span, 100)
+
+	go func() {
+		defer close(out)
+
+		for {
+			select {
+			case span, ok := <-in:
+				if !ok {
+					return
+				}
+
+				if p.match.Match(span) {
+					p.rename(span)
+				}
+
+				select {
+				case out <- span:
+				case <-ctx.Done():
+					return
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// rename applies the first rule for the span's service
+func (p *RenameProcessor) rename(span *model.Span) {
+	service := ""
+	if span.Process != nil {
+		service = span.Process.ServiceName
+	}
+
+	for i := range p.rules {
+		rule := &p.rules[i]
+		if rule.Service != "" && rule.Service != service {
+			continue
+		}
+
+		name := span.OperationName
+		if rendered, ok := rule.render(span); ok {
+			name = rendered
+		}
+		for _, re := range rule.extract {
+			name = extractToTags(span, re, name)
+		}
+		if rule.NormalizePaths {
+			name = normalizePath(name)
+		}
+
+		if name != span.OperationName {
+			span.OperationName = name
+			if p.metrics != nil {
+				p.metrics.AddCounter("rename."+p.name+".renamed", 1)
+			}
+		}
+		return
+	}
+}
+
+// render fills the template from span tags, falling back to process tags
+func (r *renameRule) render(span *model.Span) (string, bool) {
+	if r.Template == "" {
+		return "", false
+	}
+
+	var b strings.Builder
+	for i, field := range r.fields {
+		b.WriteString(r.literals[i])
+		idx := findTag(span.Tags, field)
+		switch {
+		case idx >= 0:
+			b.WriteString(span.Tags[idx].AsString())
+		case span.Process != nil && findTag(span.Process.Tags, field) >= 0:
+			b.WriteString(span.Process.Tags[findTag(span.Process.Tags, field)].AsString())
+		default:
+			return "", false
+		}
+	}
+	b.WriteString(r.literals[len(r.literals)-1])
+	return b.String(), true
+}
+
+// extractToTags moves the named groups of the first match into tags and
+// replaces each group in the name with "{group}"
+func extractToTags(span *model.Span, re *regexp.Regexp, name string) string {
+	loc := re.FindStringSubmatchIndex(name)
+	if loc == nil {
+		return name
+	}
+
+	var b strings.Builder
+	last := 0
+	for g, group := range re.SubexpNames() {
+		start, end := loc[2*g], loc[2*g+1]
+		if group == "" || start < 0 || start < last {
+			continue
+		}
+		span.SetTag(model.String(group, name[start:end]))
+		b.WriteString(name[last:start])
+		b.WriteString("{" + group + "}")
+		last = end
+	}
+	b.WriteString(name[last:])
+	return b.String()
+}
+
+// normalizePath drops the query string and replaces ID-like path
+// segments with placeholders
+func normalizePath(name string) string {
+	name, _, _ = strings.Cut(name, "?")
+
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		switch {
+		case numericSegment.MatchString(segment):
+			segments[i] = PlaceholderID
+		case uuidSegment.MatchString(segment):
+			segments[i] = PlaceholderUUID
+		case hexSegment.MatchString(segment):
+			segments[i] = PlaceholderHex
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Name returns the processor name
+func (p *RenameProcessor) Name() string {
+	return p.name
+}
+
+// MutatesData reports that the processor modifies spans in place
+func (p *RenameProcessor) MutatesData() bool {
+	return true
+}
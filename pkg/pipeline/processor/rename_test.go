@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+func TestRenameProcessor(t *testing.T) {
+	metrics := observability.NewMetrics()
+	processor, err := NewRenameProcessor("rename", RenameConfig{
+		Rules: []RenameRule{
+			{
+				Service: "users",
+				Extract: []string{`^GET /users/(?P<user_id>[0-9]+)/orders/(?P<order_id>[^/]+)$`},
+			},
+			{
+				Template:       "{http.method} {http.target}",
+				NormalizePaths: true,
+			},
+		},
+		Metrics: metrics,
+	})
+	require.NoError(t, err)
+
+	extracted := &model.Span{
+		OperationName: "GET /users/12345/orders/A-17",
+		Process:       &model.Process{ServiceName: "users"},
+	}
+	templated := &model.Span{
+		OperationName: "HTTP GET",
+		Process:       &model.Process{ServiceName: "gateway"},
+		Tags: []model.KeyValue{
+			stringTag("http.method", "GET"),
+			stringTag("http.target", "/carts/42/items/3f2504e0-4f89-11d3-9a0c-0305e82c3301/blob/deadbeefcafe?debug=1"),
+		},
+	}
+	missingTag := &model.Span{
+		OperationName: "/health/7",
+		Process:       &model.Process{ServiceName: "gateway"},
+		Tags:          []model.KeyValue{stringTag("http.method", "GET")},
+	}
+
+	in := make(chan *model.Span, 3)
+	in <- extracted
+	in <- templated
+	in <- missingTag
+	close(in)
+	for range processor.Process(context.Background(), in) {
+	}
+
+	assert.Equal(t, "GET /users/{user_id}/orders/{order_id}", extracted.OperationName)
+	userID, _ := tagByKey(extracted, "user_id")
+	assert.Equal(t, "12345", userID.VStr)
+	orderID, _ := tagByKey(extracted, "order_id")
+	assert.Equal(t, "A-17", orderID.VStr)
+
+	assert.Equal(t, "GET /carts/{id}/items/{uuid}/blob/{hex}", templated.OperationName)
+
+	// Template skipped, normalization still applies
+	assert.Equal(t, "/health/{id}", missingTag.OperationName)
+
+	assert.Equal(t, uint64(3), metrics.Snapshot().Counters["rename.rename.renamed"])
+}
+
+func TestNormalizePath(t *testing.T) {
+	tests := map[string]string{
+		"/users":                 "/users",
+		"/users/123":             "/users/{id}",
+		"GET /v1/files/0a1b2c3d": "GET /v1/files/{hex}",
+		"/cafe/abc":              "/cafe/abc",
+		"/search?q=1":            "/search",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, normalizePath(in), in)
+	}
+}
+
+func TestNewRenameProcessorValidates(t *testing.T) {
+	_, err := NewRenameProcessor("rename", RenameConfig{Rules: []RenameRule{{Service: "users"}}})
+	assert.Error(t, err)
+
+	_, err = NewRenameProcessor("rename", RenameConfig{Rules: []RenameRule{{Extract: []string{`/users/[0-9]+`}}}})
+	assert.Error(t, err)
+
+	_, err = NewRenameProcessor("rename", RenameConfig{Rules: []RenameRule{{Extract: []string{`(?P<id>[`}}}})
+	assert.Error(t, err)
+}
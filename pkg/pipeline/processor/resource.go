@@ -0,0 +1,313 @@
+package processor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+)
+
+// ResourceProcessor enriches each span's Process with attributes of the
+// host the toolkit runs on: hostname, OS, container ID and resource
+// attributes from the environment. Detection runs once, on first use,
+// and its results are cached.
+type ResourceProcessor struct {
+	name     string
+	config   ResourceConfig
+	getenv   func(string) string
+	hostname func() (string, error)
+	once     sync.Once
+	resource []model.KeyValue
+	service  string
+}
+
+// ResourcePolicy decides between detected and existing values
+type ResourcePolicy string
+
+const (
+	// PolicyMerge keeps existing process values and only adds missing ones
+	PolicyMerge ResourcePolicy = "merge"
+	// PolicyOverride replaces existing process tags with detected ones.
+	// Existing service names are only replaced with OverrideServiceName.
+	PolicyOverride ResourcePolicy = "override"
+)
+
+// Resource detectors
+const (
+	DetectorEnv       = "env"       // OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME
+	DetectorHost      = "host"      // host.name, host.id, host.arch
+	DetectorOS        = "os"        // os.type, os.name, os.version, os.description
+	DetectorContainer = "container" // container.id
+)
+
+// ResourceConfig configures the resource processor
+type ResourceConfig struct {
+	Detectors []string       // Detectors to run, in order; earlier ones win on conflicts
+	Policy    ResourcePolicy // How detected values combine with existing ones
+	RootPath  string         // Filesystem root for /etc and /proc (e.g. a mounted host root)
+
+	// OverrideServiceName replaces service names reported by the SDK
+	// with the detected one (OTEL_SERVICE_NAME of the toolkit itself).
+	// Without it the detected name only fills in missing ones, since
+	// overriding would relabel all traffic as the toolkit's service.
+	OverrideServiceName bool
+}
+
+// DefaultResourceConfig returns default resource configuration
+func DefaultResourceConfig() ResourceConfig {
+	return ResourceConfig{
+		Detectors: []string{DetectorEnv, DetectorHost, DetectorOS, DetectorContainer},
+		Policy:    PolicyMerge,
+		RootPath:  "/",
+	}
+}
+
+// NewResourceProcessor creates a new resource processor
+func NewResourceProcessor(name string, config ResourceConfig) (*ResourceProcessor, error) {
+	defaults := DefaultResourceConfig()
+	if len(config.Detectors) == 0 {
+		config.Detectors = defaults.Detectors
+	}
+	if config.Policy == "" {
+		config.Policy = defaults.Policy
+	}
+	if config.RootPath == "" {
+		config.RootPath = defaults.RootPath
+	}
+
+	if config.Policy != PolicyMerge && config.Policy != PolicyOverride {
+		return nil, fmt.Errorf("unknown resource policy: %s", config.Policy)
+	}
+	for _, detector := range config.Detectors {
+		switch detector {
+		case DetectorEnv, DetectorHost, DetectorOS, DetectorContainer:
+		default:
+			return nil, fmt.Errorf("unknown resource detector: %s", detector)
+		}
+	}
+
+	return &ResourceProcessor{
+		name:     name,
+		config:   config,
+		getenv:   os.Getenv,
+		hostname: os.Hostname,
+	}, nil
+}
+
+// Process enriches each span's process
+func (p *ResourceProcessor) Process(ctx context.Context, in <-chan *model.Span) <-chan *model.Span {
+	out := make(chan *model.Span, 100)
+
+	go func() {
+		defer close(out)
+
+		p.once.Do(p.detect)
+
+		for {
+			select {
+			case span, ok := <-in:
+				if !ok {
+					return
+				}
+
+				p.enrich(span)
+
+				select {
+				case out <- span:
+				case <-ctx.Done():
+					return
+				}
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Resource returns the detected attributes and service name
+func (p *ResourceProcessor) Resource() ([]model.KeyValue, string) {
+	p.once.Do(p.detect)
+	return p.resource, p.service
+}
+
+// enrich applies the detected resource to a span's process
+func (p *ResourceProcessor) enrich(span *model.Span) {
+	if span.Process == nil {
+		span.Process = &model.Process{}
+	}
+	process := span.Process
+
+	if p.service != "" && (process.ServiceName == "" || p.config.OverrideServiceName) {
+		process.ServiceName = p.service
+	}
+	for _, kv := range p.resource {
+		idx := findTag(process.Tags, kv.Key)
+		switch {
+		case idx < 0:
+			process.Tags = append(process.Tags, kv)
+		case p.config.Policy == PolicyOverride:
+			process.Tags[idx] = kv
+		}
+	}
+}
+
+// detect runs the configured detectors
+func (p *ResourceProcessor) detect() {
+	attrs := make(map[string]string)
+	add := func(key, value string) {
+		if _, ok := attrs[key]; !ok && value != "" {
+			attrs[key] = value
+		}
+	}
+
+	for _, detector := range p.config.Detectors {
+		switch detector {
+		case DetectorEnv:
+			p.detectEnv(add)
+		case DetectorHost:
+			p.detectHost(add)
+		case DetectorOS:
+			p.detectOS(add)
+		case DetectorContainer:
+			add("container.id", p.containerID())
+		}
+	}
+
+	if service, ok := attrs["service.name"]; ok {
+		p.service = service
+		delete(attrs, "service.name")
+	}
+
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		p.resource = append(p.resource, model.KeyValue{Key: key, VType: model.StringType, VStr: attrs[key]})
+	}
+}
+
+// detectEnv reads OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES
+// ("key1=value1,key2=value2" with percent-encoded values)
+func (p *ResourceProcessor) detectEnv(add func(key, value string)) {
+	add("service.name", strings.TrimSpace(p.getenv("OTEL_SERVICE_NAME")))
+
+	for _, pair := range strings.Split(p.getenv("OTEL_RESOURCE_ATTRIBUTES"), ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if decoded, err := url.PathUnescape(strings.TrimSpace(value)); err == nil {
+			value = decoded
+		}
+		if key != "" {
+			add(key, value)
+		}
+	}
+}
+
+// detectHost reads the hostname, machine ID and architecture
+func (p *ResourceProcessor) detectHost(add func(key, value string)) {
+	if hostname := p.readFile("proc/sys/kernel/hostname"); hostname != "" {
+		add("host.name", hostname)
+	} else if hostname, err := p.hostname(); err == nil {
+		add("host.name", hostname)
+	}
+	add("host.id", p.readFile("etc/machine-id"))
+	add("host.arch", runtime.GOARCH)
+}
+
+// detectOS reads /etc/os-release and the kernel release
+func (p *ResourceProcessor) detectOS(add func(key, value string)) {
+	add("os.type", runtime.GOOS)
+
+	release := p.osRelease()
+	add("os.name", release["NAME"])
+	add("os.version", release["VERSION_ID"])
+	description := release["PRETTY_NAME"]
+	if kernel := p.readFile("proc/sys/kernel/osrelease"); kernel != "" {
+		if description == "" {
+			description = kernel
+		} else {
+			description += " (" + kernel + ")"
+		}
+	}
+	add("os.description", description)
+}
+
+// osRelease parses os-release, preferring /etc over /usr/lib
+func (p *ResourceProcessor) osRelease() map[string]string {
+	values := make(map[string]string)
+	for _, path := range []string{"etc/os-release", "usr/lib/os-release"} {
+		file, err := os.Open(filepath.Join(p.config.RootPath, path))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+			if !ok || strings.HasPrefix(key, "#") {
+				continue
+			}
+			values[key] = strings.Trim(value, `"'`)
+		}
+		file.Close()
+		break
+	}
+	return values
+}
+
+// containerIDPattern matches the 64-hex-digit IDs of Docker and containerd
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// containerID finds the container ID in the cgroup file (cgroup v1) or
+// the mount table (cgroup v2)
+func (p *ResourceProcessor) containerID() string {
+	for _, line := range strings.Split(p.readFile("proc/self/cgroup"), "\n") {
+		if id := containerIDPattern.FindString(line); id != "" {
+			return id
+		}
+	}
+	for _, line := range strings.Split(p.readFile("proc/self/mountinfo"), "\n") {
+		if !strings.Contains(line, "/containers/") {
+			continue
+		}
+		if id := containerIDPattern.FindString(line); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// readFile returns a file below RootPath with whitespace trimmed, or ""
+func (p *ResourceProcessor) readFile(path string) string {
+	data, err := os.ReadFile(filepath.Join(p.config.RootPath, path))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Name returns the processor name
+func (p *ResourceProcessor) Name() string {
+	return p.name
+}
+
+// MutatesData reports that the processor modifies spans in place
+func (p *ResourceProcessor) MutatesData() bool {
+	return true
+}
@@ -0,0 +1,153 @@
+package processor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+)
+
+// fakeRoot writes files below a temporary filesystem root
+func fakeRoot(t *testing.T, files map[string]string) string {
+	root := t.TempDir()
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0o644))
+	}
+	return root
+}
+
+func TestResourceProcessorDetects(t *testing.T) {
+	containerID := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	root := fakeRoot(t, map[string]string{
+		"etc/os-release":            "NAME=\"Debian GNU/Linux\"\nVERSION_ID=\"12\"\nPRETTY_NAME=\"Debian GNU/Linux 12 (bookworm)\"\n",
+		"etc/machine-id":            "4f1c2d\n",
+		"proc/sys/kernel/hostname":  "node-7\n",
+		"proc/sys/kernel/osrelease": "6.1.0-18-amd64\n",
+		"proc/self/cgroup":          "0::/\n12:memory:/docker/" + containerID + "\n",
+	})
+
+	config := DefaultResourceConfig()
+	config.RootPath = root
+	processor, err := NewResourceProcessor("resource", config)
+	require.NoError(t, err)
+	env := map[string]string{
+		"OTEL_SERVICE_NAME":        "checkout",
+		"OTEL_RESOURCE_ATTRIBUTES": "deployment.environment=prod, team=payments%20core,host.name=from-env",
+	}
+	processor.getenv = func(key string) string { return env[key] }
+
+	attrs, service := processor.Resource()
+	assert.Equal(t, "checkout", service)
+
+	values := map[string]string{}
+	for _, kv := range attrs {
+		values[kv.Key] = kv.VStr
+	}
+	assert.Equal(t, map[string]string{
+		"container.id":           containerID,
+		"deployment.environment": "prod",
+		"team":                   "payments core",
+		"host.name":              "from-env", // env runs first and wins
+		"host.id":                "4f1c2d",
+		"host.arch":              runtime.GOARCH,
+		"os.type":                runtime.GOOS,
+		"os.name":                "Debian GNU/Linux",
+		"os.version":             "12",
+		"os.description":         "Debian GNU/Linux 12 (bookworm) (6.1.0-18-amd64)",
+	}, values)
+}
+
+func TestResourceProcessorPolicies(t *testing.T) {
+	root := fakeRoot(t, map[string]string{
+		"proc/sys/kernel/hostname": "node-7",
+	})
+
+	newSpan := func() *model.Span {
+		return &model.Span{Process: &model.Process{
+			ServiceName: "frontend",
+			Tags:        []model.KeyValue{{Key: "host.name", VType: model.StringType, VStr: "sdk-host"}},
+		}}
+	}
+
+	tests := []struct {
+		name            string
+		policy          ResourcePolicy
+		overrideService bool
+		service         string
+		hostname        string
+	}{
+		{"merge", PolicyMerge, false, "frontend", "sdk-host"},
+		// The toolkit's own OTEL_SERVICE_NAME must not relabel spans
+		{"override", PolicyOverride, false, "frontend", "node-7"},
+		{"override service name", PolicyOverride, true, "checkout", "node-7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor, err := NewResourceProcessor("resource", ResourceConfig{
+				Detectors:           []string{DetectorEnv, DetectorHost},
+				Policy:              tt.policy,
+				RootPath:            root,
+				OverrideServiceName: tt.overrideService,
+			})
+			require.NoError(t, err)
+			processor.getenv = func(key string) string {
+				if key == "OTEL_SERVICE_NAME" {
+					return "checkout"
+				}
+				return ""
+			}
+
+			in := make(chan *model.Span, 2)
+			in <- newSpan()
+			in <- &model.Span{} // No process yet
+			close(in)
+
+			var spans []*model.Span
+			for span := range processor.Process(context.Background(), in) {
+				spans = append(spans, span)
+			}
+			require.Len(t, spans, 2)
+
+			process := spans[0].Process
+			assert.Equal(t, tt.service, process.ServiceName)
+			assert.Equal(t, tt.hostname, process.Tags[findTag(process.Tags, "host.name")].VStr)
+			assert.GreaterOrEqual(t, findTag(process.Tags, "host.arch"), 0)
+
+			require.NotNil(t, spans[1].Process)
+			assert.Equal(t, "checkout", spans[1].Process.ServiceName)
+			assert.Equal(t, "node-7", spans[1].Process.Tags[findTag(spans[1].Process.Tags, "host.name")].VStr)
+		})
+	}
+}
+
+func TestResourceProcessorContainerIDFromMountinfo(t *testing.T) {
+	containerID := "fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210"
+	root := fakeRoot(t, map[string]string{
+		"proc/self/cgroup": "0::/\n",
+		"proc/self/mountinfo": "1 0 0:1 / / rw - overlay overlay rw\n" +
+			"2 1 8:1 /var/lib/docker/containers/" + containerID + "/hostname /etc/hostname rw - ext4 /dev/sda1 rw\n",
+	})
+
+	processor, err := NewResourceProcessor("resource", ResourceConfig{Detectors: []string{DetectorContainer}, RootPath: root})
+	require.NoError(t, err)
+
+	attrs, _ := processor.Resource()
+	require.Len(t, attrs, 1)
+	assert.Equal(t, containerID, attrs[0].VStr)
+}
+
+func TestNewResourceProcessorValidates(t *testing.T) {
+	_, err := NewResourceProcessor("resource", ResourceConfig{Policy: "replace"})
+	assert.Error(t, err)
+
+	_, err = NewResourceProcessor("resource", ResourceConfig{Detectors: []string{"gcp"}})
+	assert.Error(t, err)
+}
@@ -3,10 +3,11 @@ package processor
 import (
 	"context"
 	"math/rand"
-	"sync"
 	"time"
 
+	"github.com/vjranagit/jaeger-toolkit/pkg/filter"
 	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
 )
 
 // SamplingProcessor implements adaptive sampling based on span characteristics
@@ -21,11 +22,10 @@ type SamplingProcessor struct {
 	slowThreshold      time.Duration
 	
 	// Adaptive sampling state
-	mu                sync.RWMutex
-	recentErrors      int
-	recentTotal       int
-	adaptiveRate      float64
-	adaptiveWindow    int
+	controller *AdaptiveController
+
+	// Spans not matched bypass sampling
+	match *filter.Matcher
 	
 	rng *rand.Rand
 }
@@ -35,7 +35,9 @@ type SamplingConfig struct {
 	BaseSampleRate     float64       // Base probability (0.0 - 1.0)
 	AlwaysSampleErrors bool          // Always keep error spans
 	SlowThreshold      time.Duration // Always keep spans slower than this
-	AdaptiveWindow     int           // Number of spans to track for adaptation
+	Adaptive           AdaptiveConfig         // Feedback controller for the probabilistic rate
+	Metrics            *observability.Metrics // Optional; receives controller gauges
+	Match              *filter.Matcher        // Optional include/exclude; other spans pass through
 }
 
 // DefaultSamplingConfig returns sensible defaults
@@ -44,7 +46,7 @@ func DefaultSamplingConfig() SamplingConfig {
 		BaseSampleRate:     0.1,               // 10% baseline
 		AlwaysSampleErrors: true,              // Keep all errors
 		SlowThreshold:      1 * time.Second,   // Keep slow spans
-		AdaptiveWindow:     1000,              // Adapt over 1k spans
+		Adaptive:           DefaultAdaptiveConfig(),
 	}
 }
 
@@ -55,8 +57,8 @@ func NewSamplingProcessor(name string, config SamplingConfig) *SamplingProcessor
 		baseSampleRate:     config.BaseSampleRate,
 		alwaysSampleErrors: config.AlwaysSampleErrors,
 		slowThreshold:      config.SlowThreshold,
-		adaptiveRate:       config.BaseSampleRate,
-		adaptiveWindow:     config.AdaptiveWindow,
+		controller:         NewAdaptiveController(name, config.BaseSampleRate, config.Adaptive, config.Metrics),
+		match:              config.Match,
 		rng:                rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
@@ -75,7 +77,17 @@ func (p *SamplingProcessor) Process(ctx context.Context, in <-chan *model.Span)
 					return
 				}
 
-				if p.shouldSample(span) {
+				if !p.match.Match(span) {
+					select {
+					case out <- span:
+					case <-ctx.Done():
+						return
+					}
+					continue
+				}
+
+				if kept, rule, rate := p.decide(span); kept {
+					p.tagSpan(span, rule, rate)
 					select {
 					case out <- span:
 					case <-ctx.Done():
@@ -93,18 +105,45 @@ func (p *SamplingProcessor) Process(ctx context.Context, in <-chan *model.Span)
 	return out
 }
 
+// SamplingRule identifies which rule kept a span
+type SamplingRule string
+
+const (
+	// RuleError marks spans kept because they represent an error
+	RuleError SamplingRule = "error"
+	// RuleSlow marks spans kept because they exceeded the slow threshold
+	RuleSlow SamplingRule = "slow"
+	// RuleAdaptive marks spans kept by the probabilistic adaptive rate
+	RuleAdaptive SamplingRule = "adaptive"
+)
+
+// Tags recorded on kept spans, following the Jaeger client convention.
+// Analytics can weight each span by 1/sampler.param to extrapolate
+// back to the true traffic volume.
+const (
+	SamplerTypeTagKey  = "sampler.type"
+	SamplerParamTagKey = "sampler.param"
+)
+
 // shouldSample determines if a span should be kept
 func (p *SamplingProcessor) shouldSample(span *model.Span) bool {
+	kept, _, _ := p.decide(span)
+	return kept
+}
+
+// decide returns the sampling decision along with the rule and the
+// probability with which the span was kept
+func (p *SamplingProcessor) decide(span *model.Span) (bool, SamplingRule, float64) {
 	// Priority 1: Always sample errors if configured
 	if p.alwaysSampleErrors && p.isError(span) {
 		p.recordSample(true)
-		return true
+		return true, RuleError, 1.0
 	}
 
 	// Priority 2: Always sample slow requests
 	if span.Duration >= p.slowThreshold {
 		p.recordSample(false)
-		return true
+		return true, RuleSlow, 1.0
 	}
 
 	// Priority 3: Adaptive sampling based on recent error rate
@@ -116,7 +155,32 @@ func (p *SamplingProcessor) shouldSample(span *model.Span) bool {
 	
 	p.recordSample(p.isError(span))
 	
-	return decision
+	return decision, RuleAdaptive, rate
+}
+
+// tagSpan records the sampling rule and probability on a kept span,
+// replacing any values set by an upstream sampler
+func (p *SamplingProcessor) tagSpan(span *model.Span, rule SamplingRule, rate float64) {
+	typeTag := model.KeyValue{Key: SamplerTypeTagKey, VType: model.StringType, VStr: string(rule)}
+	paramTag := model.KeyValue{Key: SamplerParamTagKey, VType: model.Float64Type, VFloat64: rate}
+
+	typeSet, paramSet := false, false
+	for i := range span.Tags {
+		switch span.Tags[i].Key {
+		case SamplerTypeTagKey:
+			span.Tags[i] = typeTag
+			typeSet = true
+		case SamplerParamTagKey:
+			span.Tags[i] = paramTag
+			paramSet = true
+		}
+	}
+	if !typeSet {
+		span.Tags = append(span.Tags, typeTag)
+	}
+	if !paramSet {
+		span.Tags = append(span.Tags, paramTag)
+	}
 }
 
 // deterministicSample uses trace ID for consistent sampling decisions
@@ -129,51 +193,23 @@ func (p *SamplingProcessor) deterministicSample(traceID model.TraceID, rate floa
 
 // isError checks if span represents an error
 func (p *SamplingProcessor) isError(span *model.Span) bool {
-	for _, tag := range span.Tags {
-		if tag.Key == "error" && tag.VType == model.BoolType && tag.VBool {
-			return true
-		}
-		if tag.Key == "http.status_code" && tag.VType == model.Int64Type && tag.VInt64 >= 500 {
-			return true
-		}
-	}
-	return false
+	return isErrorSpan(span)
 }
 
-// recordSample updates adaptive sampling state
-func (p *SamplingProcessor) recordSample(isError bool) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	p.recentTotal++
-	if isError {
-		p.recentErrors++
-	}
-
-	// Recalculate adaptive rate periodically
-	if p.recentTotal >= p.adaptiveWindow {
-		errorRate := float64(p.recentErrors) / float64(p.recentTotal)
-
-		// Increase sampling if error rate is high
-		if errorRate > 0.05 { // More than 5% errors
-			p.adaptiveRate = min(1.0, p.baseSampleRate*2.0)
-		} else if errorRate > 0.01 { // More than 1% errors
-			p.adaptiveRate = min(1.0, p.baseSampleRate*1.5)
-		} else {
-			p.adaptiveRate = p.baseSampleRate
-		}
+// isErrorSpan reports whether a span represents an error.
+// Shared by every processor that classifies spans by outcome.
+func isErrorSpan(span *model.Span) bool {
+	return span.EffectiveStatus().Code == model.StatusError
+}
 
-		// Reset counters
-		p.recentErrors = 0
-		p.recentTotal = 0
-	}
+// recordSample feeds the adaptive controller
+func (p *SamplingProcessor) recordSample(isError bool) {
+	p.controller.Observe(isError)
 }
 
 // getAdaptiveRate returns current adaptive sampling rate
 func (p *SamplingProcessor) getAdaptiveRate() float64 {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-	return p.adaptiveRate
+	return p.controller.Rate()
 }
 
 // Name returns the processor name
@@ -181,16 +217,21 @@ func (p *SamplingProcessor) Name() string {
 	return p.name
 }
 
+// MutatesData reports that the processor modifies spans in place
+func (p *SamplingProcessor) MutatesData() bool {
+	return true
+}
+
 // GetStats returns current sampling statistics
 func (p *SamplingProcessor) GetStats() SamplingStats {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
+	state := p.controller.State()
 
 	return SamplingStats{
 		BaseSampleRate:   p.baseSampleRate,
-		AdaptiveRate:     p.adaptiveRate,
-		RecentErrorCount: p.recentErrors,
-		RecentTotalCount: p.recentTotal,
+		AdaptiveRate:     state.Rate,
+		RecentErrorCount: int(state.WindowErrors),
+		RecentTotalCount: int(state.WindowTotal),
+		Controller:       state,
 	}
 }
 
@@ -200,6 +241,7 @@ type SamplingStats struct {
 	AdaptiveRate     float64
 	RecentErrorCount int
 	RecentTotalCount int
+	Controller       ControllerState
 }
 
 func min(a, b float64) float64 {
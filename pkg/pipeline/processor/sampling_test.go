@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/vjranagit/jaeger-toolkit/pkg/filter"
 	"github.com/vjranagit/jaeger-toolkit/pkg/model"
 )
 
@@ -71,9 +72,12 @@ func TestSamplingProcessorBaseSamplingRate(t *testing.T) {
 func TestSamplingProcessorAdaptiveRate(t *testing.T) {
 	config := DefaultSamplingConfig()
 	config.BaseSampleRate = 0.1
-	config.AdaptiveWindow = 100 // Small window for testing
+	config.Adaptive.Window = 10 * time.Second
 	processor := NewSamplingProcessor("test-sampler", config)
 
+	now := time.Now()
+	processor.controller.now = func() time.Time { return now }
+
 	// Simulate high error rate
 	for i := 0; i < 50; i++ {
 		processor.recordSample(true) // Error
@@ -82,6 +86,10 @@ func TestSamplingProcessorAdaptiveRate(t *testing.T) {
 		processor.recordSample(false) // No error
 	}
 
+	// Close the window
+	now = now.Add(config.Adaptive.Window)
+	processor.recordSample(false)
+
 	// Error rate is 50%, should increase adaptive rate
 	stats := processor.GetStats()
 	assert.Greater(t, stats.AdaptiveRate, config.BaseSampleRate)
@@ -134,3 +142,99 @@ func TestSamplingProcessorHTTPErrorCodes(t *testing.T) {
 
 	assert.True(t, processor.shouldSample(span))
 }
+
+func TestSamplingProcessorErrorStatus(t *testing.T) {
+	config := DefaultSamplingConfig()
+	config.BaseSampleRate = 0.0
+	processor := NewSamplingProcessor("test-sampler", config)
+
+	// OTLP status, as a field and as a Jaeger convention tag
+	assert.True(t, processor.shouldSample(&model.Span{
+		TraceID: model.TraceID{Low: 2},
+		Status:  &model.Status{Code: model.StatusError, Message: "timeout"},
+	}))
+	assert.True(t, processor.shouldSample(&model.Span{
+		TraceID: model.TraceID{Low: 2},
+		Tags:    []model.KeyValue{model.String(model.TagOTelStatusCode, "ERROR")},
+	}))
+	assert.False(t, processor.shouldSample(&model.Span{
+		TraceID: model.TraceID{Low: 2},
+		Status:  &model.Status{Code: model.StatusOK},
+	}))
+}
+
+func TestSamplingProcessorTagsKeptSpans(t *testing.T) {
+	config := DefaultSamplingConfig()
+	config.BaseSampleRate = 1.0
+	config.SlowThreshold = 500 * time.Millisecond
+	processor := NewSamplingProcessor("test-sampler", config)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	in := make(chan *model.Span, 3)
+	out := processor.Process(ctx, in)
+
+	in <- &model.Span{
+		TraceID: model.TraceID{Low: 1},
+		Tags:    []model.KeyValue{{Key: "error", VType: model.BoolType, VBool: true}},
+	}
+	in <- &model.Span{TraceID: model.TraceID{Low: 2}, Duration: 1 * time.Second}
+	in <- &model.Span{
+		TraceID: model.TraceID{Low: 3},
+		Tags:    []model.KeyValue{{Key: SamplerTypeTagKey, VType: model.StringType, VStr: "const"}},
+	}
+	close(in)
+
+	rules := make([]string, 0)
+	for span := range out {
+		var rule string
+		var param float64
+		count := 0
+		for _, tag := range span.Tags {
+			switch tag.Key {
+			case SamplerTypeTagKey:
+				rule = tag.VStr
+				count++
+			case SamplerParamTagKey:
+				param = tag.VFloat64
+				count++
+			}
+		}
+		assert.Equal(t, 2, count)
+		assert.Equal(t, 1.0, param)
+		rules = append(rules, rule)
+	}
+
+	assert.Equal(t, []string{"error", "slow", "adaptive"}, rules)
+}
+
+func TestSamplingProcessorMatchBypass(t *testing.T) {
+	match, err := filter.NewMatcher(`service == "checkout"`, "")
+	assert.NoError(t, err)
+
+	config := DefaultSamplingConfig()
+	config.BaseSampleRate = 0.0
+	config.AlwaysSampleErrors = false
+	config.Match = match
+	processor := NewSamplingProcessor("test-sampler", config)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	in := make(chan *model.Span, 2)
+	out := processor.Process(ctx, in)
+
+	in <- &model.Span{TraceID: model.TraceID{Low: ^uint64(0)}, Process: &model.Process{ServiceName: "checkout"}}
+	in <- &model.Span{TraceID: model.TraceID{Low: ^uint64(0)}, Process: &model.Process{ServiceName: "inventory"}}
+	close(in)
+
+	services := make([]string, 0)
+	for span := range out {
+		services = append(services, span.Process.ServiceName)
+		assert.Empty(t, span.Tags)
+	}
+
+	// Only the matched service is subject to sampling
+	assert.Equal(t, []string{"inventory"}, services)
+}
@@ -0,0 +1,314 @@
+package processor
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+// SpanMetricsProcessor derives RED (rate, errors, duration) metrics from
+// the spans flowing through it. Spans pass through unchanged, so it must
+// be placed before sampling to count the full traffic.
+type SpanMetricsProcessor struct {
+	name          string
+	buckets       []time.Duration
+	maxSeries     int
+	flushInterval time.Duration
+	stream        *snapshotStream[[]SpanMetric]
+	metrics       *observability.Metrics
+
+	mu     sync.RWMutex
+	series map[spanMetricKey]*spanSeries
+}
+
+// SpanMetricsConfig configures the span metrics processor
+type SpanMetricsConfig struct {
+	Buckets       []time.Duration        // Latency histogram upper bounds
+	MaxSeries     int                    // Cardinality cap, including the overflow series
+	FlushInterval time.Duration          // Period of the metrics stream (0 = disabled)
+	Metrics       *observability.Metrics // Optional; counts spans recorded as overflow
+}
+
+// DefaultSpanMetricsConfig returns default span metrics configuration
+func DefaultSpanMetricsConfig() SpanMetricsConfig {
+	return SpanMetricsConfig{
+		Buckets: []time.Duration{
+			2 * time.Millisecond, 4 * time.Millisecond, 6 * time.Millisecond, 8 * time.Millisecond,
+			10 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond,
+			400 * time.Millisecond, 800 * time.Millisecond, 1 * time.Second, 1400 * time.Millisecond,
+			2 * time.Second, 5 * time.Second, 10 * time.Second, 15 * time.Second,
+		},
+		MaxSeries:     10000,
+		FlushInterval: 15 * time.Second,
+	}
+}
+
+// OverflowValue replaces every label (service, operation and status) of
+// spans that would create a series beyond MaxSeries, so they all share
+// one overflow series
+const OverflowValue = "__overflow__"
+
+// overflowKey is the key of the overflow series
+var overflowKey = spanMetricKey{service: OverflowValue, operation: OverflowValue, status: OverflowValue}
+
+// Span status label values
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// spanMetricKey identifies a series
+type spanMetricKey struct {
+	service   string
+	operation string
+	status    string
+}
+
+// spanSeries holds the counters of one series; updated with atomics
+type spanSeries struct {
+	calls       atomic.Uint64
+	durationSum atomic.Int64    // Nanoseconds
+	buckets     []atomic.Uint64 // Non-cumulative; last bucket is +Inf
+}
+
+// SpanMetric is a point-in-time view of one series
+type SpanMetric struct {
+	Service     string
+	Operation   string
+	Status      string
+	Calls       uint64
+	DurationSum time.Duration
+	Buckets     []time.Duration // Upper bounds
+	Counts      []uint64        // Cumulative counts per bound, then +Inf
+}
+
+// NewSpanMetricsProcessor creates a new span metrics processor
+func NewSpanMetricsProcessor(name string, config SpanMetricsConfig) *SpanMetricsProcessor {
+	defaults := DefaultSpanMetricsConfig()
+	if len(config.Buckets) == 0 {
+		config.Buckets = defaults.Buckets
+	}
+	if config.MaxSeries <= 0 {
+		config.MaxSeries = defaults.MaxSeries
+	}
+
+	buckets := append([]time.Duration(nil), config.Buckets...)
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i] < buckets[j] })
+
+	return &SpanMetricsProcessor{
+		name:          name,
+		buckets:       buckets,
+		maxSeries:     config.MaxSeries,
+		flushInterval: config.FlushInterval,
+		stream:        newSnapshotStream[[]SpanMetric](),
+		metrics:       config.Metrics,
+		series:        make(map[spanMetricKey]*spanSeries),
+	}
+}
+
+// Process records metrics for each span and passes it on. It may be
+// called more than once; all calls record into the same series.
+func (p *SpanMetricsProcessor) Process(ctx context.Context, in <-chan *model.Span) <-chan *model.Span {
+	out := make(chan *model.Span, 100)
+
+	p.stream.start()
+	go func() {
+		defer close(out)
+		defer p.stream.stop()
+
+		var tick <-chan time.Time
+		if p.flushInterval > 0 {
+			ticker := time.NewTicker(p.flushInterval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for {
+			select {
+			case span, ok := <-in:
+				if !ok {
+					p.publish()
+					return
+				}
+
+				p.record(span)
+
+				select {
+				case out <- span:
+				case <-ctx.Done():
+					return
+				}
+
+			case <-tick:
+				p.publish()
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// record updates the series of a span
+func (p *SpanMetricsProcessor) record(span *model.Span) {
+	key := spanMetricKey{
+		operation: span.OperationName,
+		status:    StatusOK,
+	}
+	if span.Process != nil {
+		key.service = span.Process.ServiceName
+	}
+	if isErrorSpan(span) {
+		key.status = StatusError
+	}
+
+	series := p.getSeries(key)
+	series.calls.Add(1)
+	series.durationSum.Add(int64(span.Duration))
+	idx := sort.Search(len(p.buckets), func(i int) bool { return span.Duration <= p.buckets[i] })
+	series.buckets[idx].Add(1)
+}
+
+// getSeries returns the series for key, creating it if needed
+func (p *SpanMetricsProcessor) getSeries(key spanMetricKey) *spanSeries {
+	p.mu.RLock()
+	series, ok := p.series[key]
+	p.mu.RUnlock()
+	if ok {
+		return series
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if series, ok := p.series[key]; ok {
+		return series
+	}
+	// One slot is kept for the overflow series, so there are never more
+	// than maxSeries series
+	if len(p.series) >= p.maxSeries-1 && key != overflowKey {
+		if p.metrics != nil {
+			p.metrics.AddCounter("spanmetrics."+p.name+".overflow", 1)
+		}
+		if series, ok := p.series[overflowKey]; ok {
+			return series
+		}
+		key = overflowKey
+	}
+	series = &spanSeries{buckets: make([]atomic.Uint64, len(p.buckets)+1)}
+	p.series[key] = series
+	return series
+}
+
+// publish sends a snapshot on the stream without blocking
+func (p *SpanMetricsProcessor) publish() {
+	p.stream.publish(p.Snapshot)
+}
+
+// Stream returns the periodic metrics stream. A snapshot is emitted every
+// FlushInterval and when the input closes; slow consumers miss snapshots
+// rather than blocking span processing. The stream is closed when the
+// last running Process call returns.
+func (p *SpanMetricsProcessor) Stream() <-chan []SpanMetric {
+	return p.stream.ch
+}
+
+// Snapshot returns all series sorted by service, operation and status
+func (p *SpanMetricsProcessor) Snapshot() []SpanMetric {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	metrics := make([]SpanMetric, 0, len(p.series))
+	for key, series := range p.series {
+		metric := SpanMetric{
+			Service:     key.service,
+			Operation:   key.operation,
+			Status:      key.status,
+			Calls:       series.calls.Load(),
+			DurationSum: time.Duration(series.durationSum.Load()),
+			Buckets:     p.buckets,
+			Counts:      make([]uint64, len(series.buckets)),
+		}
+		var cumulative uint64
+		for i := range series.buckets {
+			cumulative += series.buckets[i].Load()
+			metric.Counts[i] = cumulative
+		}
+		metrics = append(metrics, metric)
+	}
+
+	sort.Slice(metrics, func(i, j int) bool {
+		a, b := metrics[i], metrics[j]
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		if a.Operation != b.Operation {
+			return a.Operation < b.Operation
+		}
+		return a.Status < b.Status
+	})
+	return metrics
+}
+
+// ServeHTTP writes the metrics in Prometheus text format
+func (p *SpanMetricsProcessor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", observability.PrometheusContentType)
+	snapshot := p.Snapshot()
+
+	observability.WritePrometheusHeader(w, "traces_span_metrics_calls_total", "Number of spans by service, operation and status", "counter")
+	for _, m := range snapshot {
+		observability.WritePrometheusSample(w, "traces_span_metrics_calls_total", m.labels(), float64(m.Calls))
+	}
+
+	observability.WritePrometheusHeader(w, "traces_span_metrics_duration_seconds", "Span duration by service, operation and status", "histogram")
+	for _, m := range snapshot {
+		labels := m.labels()
+		for i, count := range m.Counts {
+			le := math.Inf(1)
+			if i < len(m.Buckets) {
+				le = m.Buckets[i].Seconds()
+			}
+			bucketLabels := append(labels[:len(labels):len(labels)], observability.Label{Name: "le", Value: formatBound(le)})
+			observability.WritePrometheusSample(w, "traces_span_metrics_duration_seconds_bucket", bucketLabels, float64(count))
+		}
+		observability.WritePrometheusSample(w, "traces_span_metrics_duration_seconds_sum", labels, m.DurationSum.Seconds())
+		observability.WritePrometheusSample(w, "traces_span_metrics_duration_seconds_count", labels, float64(m.Calls))
+	}
+}
+
+// labels returns the Prometheus labels of a series
+func (m SpanMetric) labels() []observability.Label {
+	return []observability.Label{
+		{Name: "service_name", Value: m.Service},
+		{Name: "span_name", Value: m.Operation},
+		{Name: "status_code", Value: m.Status},
+	}
+}
+
+// formatBound renders a histogram bound for the le label
+func formatBound(le float64) string {
+	if math.IsInf(le, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(le, 'g', -1, 64)
+}
+
+// Name returns the processor name
+func (p *SpanMetricsProcessor) Name() string {
+	return p.name
+}
+
+// MutatesData reports that the processor only reads spans
+func (p *SpanMetricsProcessor) MutatesData() bool {
+	return false
+}
@@ -0,0 +1,141 @@
+package processor
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"github.com/vjranagit/jaeger-toolkit/pkg/observability"
+)
+
+func metricsSpan(service, operation string, d time.Duration, isError bool) *model.Span {
+	span := &model.Span{
+		OperationName: operation,
+		Duration:      d,
+		Process:       &model.Process{ServiceName: service},
+	}
+	if isError {
+		span.Tags = append(span.Tags, model.KeyValue{Key: "error", VType: model.BoolType, VBool: true})
+	}
+	return span
+}
+
+func TestSpanMetricsProcessorRED(t *testing.T) {
+	config := DefaultSpanMetricsConfig()
+	config.Buckets = []time.Duration{10 * time.Millisecond, 100 * time.Millisecond}
+	config.FlushInterval = 0
+	processor := NewSpanMetricsProcessor("red", config)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	in := make(chan *model.Span, 10)
+	out := processor.Process(ctx, in)
+
+	in <- metricsSpan("checkout", "POST /cart", 5*time.Millisecond, false)
+	in <- metricsSpan("checkout", "POST /cart", 50*time.Millisecond, false)
+	in <- metricsSpan("checkout", "POST /cart", 500*time.Millisecond, false)
+	in <- metricsSpan("checkout", "POST /cart", 20*time.Millisecond, true)
+	close(in)
+
+	passed := 0
+	for range out {
+		passed++
+	}
+	assert.Equal(t, 4, passed)
+
+	// Final snapshot is published when the input closes
+	streamed, ok := <-processor.Stream()
+	require.True(t, ok)
+
+	snapshot := processor.Snapshot()
+	assert.Equal(t, snapshot, streamed)
+	require.Len(t, snapshot, 2)
+
+	errs, okSeries := snapshot[0], snapshot[1]
+	assert.Equal(t, StatusError, errs.Status)
+	assert.Equal(t, uint64(1), errs.Calls)
+
+	assert.Equal(t, "checkout", okSeries.Service)
+	assert.Equal(t, "POST /cart", okSeries.Operation)
+	assert.Equal(t, uint64(3), okSeries.Calls)
+	assert.Equal(t, 555*time.Millisecond, okSeries.DurationSum)
+	assert.Equal(t, []uint64{1, 2, 3}, okSeries.Counts)
+}
+
+func TestSpanMetricsProcessorMaxSeries(t *testing.T) {
+	metrics := observability.NewMetrics()
+	config := DefaultSpanMetricsConfig()
+	config.MaxSeries = 3
+	config.Metrics = metrics
+	processor := NewSpanMetricsProcessor("red", config)
+
+	processor.record(metricsSpan("svc", "a", time.Millisecond, false))
+	processor.record(metricsSpan("svc", "b", time.Millisecond, false))
+	// New services and statuses do not add overflow series
+	processor.record(metricsSpan("svc", "c", time.Millisecond, false))
+	processor.record(metricsSpan("other", "d", time.Millisecond, true))
+	processor.record(metricsSpan("third", "e", time.Millisecond, false))
+	processor.record(metricsSpan("svc", "a", time.Millisecond, false))
+
+	snapshot := processor.Snapshot()
+	require.Len(t, snapshot, 3)
+	overflow := snapshot[0]
+	assert.Equal(t, OverflowValue, overflow.Service)
+	assert.Equal(t, OverflowValue, overflow.Operation)
+	assert.Equal(t, OverflowValue, overflow.Status)
+	assert.Equal(t, uint64(3), overflow.Calls)
+	assert.Equal(t, uint64(2), snapshot[1].Calls, "existing series keep counting")
+	assert.Equal(t, uint64(3), metrics.Snapshot().Counters["spanmetrics.red.overflow"])
+}
+
+func TestSpanMetricsProcessorPrometheus(t *testing.T) {
+	config := DefaultSpanMetricsConfig()
+	config.Buckets = []time.Duration{100 * time.Millisecond}
+	processor := NewSpanMetricsProcessor("red", config)
+	processor.record(metricsSpan("checkout", `GET "/"`, 50*time.Millisecond, false))
+
+	rec := httptest.NewRecorder()
+	processor.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics/spans", nil))
+
+	body := rec.Body.String()
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, body, "# TYPE traces_span_metrics_calls_total counter\n")
+	assert.Contains(t, body, `traces_span_metrics_calls_total{service_name="checkout",span_name="GET \"/\"",status_code="ok"} 1`)
+	assert.Contains(t, body, `traces_span_metrics_duration_seconds_bucket{service_name="checkout",span_name="GET \"/\"",status_code="ok",le="0.1"} 1`)
+	assert.Contains(t, body, `traces_span_metrics_duration_seconds_bucket{service_name="checkout",span_name="GET \"/\"",status_code="ok",le="+Inf"} 1`)
+	assert.Contains(t, body, `traces_span_metrics_duration_seconds_sum{service_name="checkout",span_name="GET \"/\"",status_code="ok"} 0.05`)
+}
+
+func TestSpanMetricsProcessorRestart(t *testing.T) {
+	config := DefaultSpanMetricsConfig()
+	config.FlushInterval = time.Millisecond
+	processor := NewSpanMetricsProcessor("red", config)
+
+	// Two concurrent calls, then one after the stream has closed
+	run := func() {
+		in := make(chan *model.Span, 1)
+		in <- metricsSpan("checkout", "GET /", time.Millisecond, false)
+		close(in)
+		for range processor.Process(context.Background(), in) {
+		}
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run()
+	}()
+	run()
+	<-done
+	for range processor.Stream() {
+	}
+	run()
+
+	snapshot := processor.Snapshot()
+	require.Len(t, snapshot, 1)
+	assert.Equal(t, uint64(3), snapshot[0].Calls)
+}
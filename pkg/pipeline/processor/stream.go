@@ -0,0 +1,52 @@
+package processor
+
+import "sync"
+
+// snapshotStream is a snapshot channel shared by every Process call of a
+// processor. It is closed once, when the last running call returns;
+// later snapshots are skipped, so a processor can be wired twice or
+// restarted without sending on a closed channel.
+type snapshotStream[T any] struct {
+	mu      sync.Mutex
+	ch      chan T
+	running int
+	closed  bool
+}
+
+// newSnapshotStream creates a stream buffering one snapshot
+func newSnapshotStream[T any]() *snapshotStream[T] {
+	return &snapshotStream[T]{ch: make(chan T, 1)}
+}
+
+// start registers a running Process call; call it before its goroutine
+// starts
+func (s *snapshotStream[T]) start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running++
+}
+
+// stop unregisters a Process call, closing the stream after the last
+func (s *snapshotStream[T]) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	if s.running == 0 && !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// publish sends a snapshot without blocking. A consumer that is behind
+// gets the next snapshot instead.
+func (s *snapshotStream[T]) publish(snapshot func() T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.ch <- snapshot():
+	default:
+	}
+}
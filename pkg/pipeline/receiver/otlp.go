@@ -4,10 +4,13 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"strings"
 	"sync"
 
 	"github.com/vjranagit/jaeger-toolkit/pkg/model"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 )
 
 // OTLPReceiver receives spans via OTLP gRPC protocol
@@ -16,13 +19,15 @@ type OTLPReceiver struct {
 	endpoint string
 	server   *grpc.Server
 	spanChan chan *model.Span
+	metadata []string
 	mu       sync.Mutex
 	started  bool
 }
 
 // OTLPConfig configures the OTLP receiver
 type OTLPConfig struct {
-	Endpoint string // e.g., "0.0.0.0:4317"
+	Endpoint        string   // e.g., "0.0.0.0:4317"
+	IncludeMetadata []string // Request headers copied into span tags (see model.TagMetadataPrefix)
 }
 
 // NewOTLPReceiver creates a new OTLP receiver
@@ -31,6 +36,7 @@ func NewOTLPReceiver(name string, config OTLPConfig) *OTLPReceiver {
 		name:     name,
 		endpoint: config.Endpoint,
 		spanChan: make(chan *model.Span, 1000), // Buffered channel
+		metadata: config.IncludeMetadata,
 	}
 }
 
@@ -83,8 +89,14 @@ func (r *OTLPReceiver) Name() string {
 	return r.name
 }
 
-// SubmitSpan is called by the gRPC handler to submit spans
+// SubmitSpan is called by the gRPC handler to submit spans. Spans are
+// normalized first (see model.Span.Normalize), with fixes recorded in
+// their Warnings; spans that cannot be fixed are dropped.
 func (r *OTLPReceiver) SubmitSpan(span *model.Span) {
+	if err := span.Normalize(); err != nil {
+		fmt.Printf("Warning: dropping span: %v\n", err)
+		return
+	}
 	select {
 	case r.spanChan <- span:
 	default:
@@ -92,3 +104,51 @@ func (r *OTLPReceiver) SubmitSpan(span *model.Span) {
 		fmt.Printf("Warning: span channel full, dropping span\n")
 	}
 }
+
+// SubmitSpanWithContext submits a span received on a gRPC call, tagging
+// it with the caller's IP from the connection and the request headers
+// listed in IncludeMetadata. Tags the span already has are kept, except
+// peer IP and metadata tags sent by the client itself: these are
+// removed, so a client cannot pose as another pod or tenant.
+func (r *OTLPReceiver) SubmitSpanWithContext(ctx context.Context, span *model.Span) {
+	span.Tags = removeReceiverTags(span.Tags)
+	if span.Process != nil {
+		span.Process.Tags = removeReceiverTags(span.Process.Tags)
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr := p.Addr.String()
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			addr = host
+		}
+		span.Tags = append(span.Tags, model.String(model.TagPeerIP, addr))
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, header := range r.metadata {
+			if values := md.Get(header); len(values) > 0 {
+				addTag(span, model.String(model.TagMetadataPrefix+strings.ToLower(header), strings.Join(values, ",")))
+			}
+		}
+	}
+	r.SubmitSpan(span)
+}
+
+// addTag appends a tag unless the span already has one with its key
+func addTag(span *model.Span, kv model.KeyValue) {
+	if _, ok := span.Tag(kv.Key); !ok {
+		span.Tags = append(span.Tags, kv)
+	}
+}
+
+// removeReceiverTags removes the tags the receiver sets
+func removeReceiverTags(tags []model.KeyValue) []model.KeyValue {
+	kept := tags[:0]
+	for _, tag := range tags {
+		if tag.Key != model.TagPeerIP && !strings.HasPrefix(tag.Key, model.TagMetadataPrefix) {
+			kept = append(kept, tag)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
@@ -0,0 +1,123 @@
+package receiver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vjranagit/jaeger-toolkit/pkg/model"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestSubmitSpanWithContextTagsPeer(t *testing.T) {
+	r := NewOTLPReceiver("otlp", OTLPConfig{})
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("10.1.0.5"), Port: 43122},
+	})
+	r.SubmitSpanWithContext(ctx, &model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 1})
+	r.SubmitSpanWithContext(context.Background(), &model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 2})
+
+	span := <-r.spanChan
+	require.Len(t, span.Tags, 1)
+	assert.Equal(t, model.TagPeerIP, span.Tags[0].Key)
+	assert.Equal(t, "10.1.0.5", span.Tags[0].VStr)
+
+	span = <-r.spanChan
+	assert.Empty(t, span.Tags)
+}
+
+func TestSubmitSpanWithContextKeepsSpanTags(t *testing.T) {
+	r := NewOTLPReceiver("otlp", OTLPConfig{IncludeMetadata: []string{"X-Tenant"}})
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("10.1.0.5"), Port: 43122},
+	})
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-tenant", "acme"))
+	r.SubmitSpanWithContext(ctx, &model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 1, Tags: []model.KeyValue{
+		// The application's remote peer, not the collector's connection
+		model.String("net.sock.peer.addr", "192.168.1.20"),
+	}})
+
+	span := <-r.spanChan
+	require.Len(t, span.Tags, 3)
+	assert.Equal(t, model.String("net.sock.peer.addr", "192.168.1.20"), span.Tags[0])
+	assert.Equal(t, model.String(model.TagPeerIP, "10.1.0.5"), span.Tags[1])
+	assert.Equal(t, model.String("metadata.x-tenant", "acme"), span.Tags[2])
+}
+
+func TestSubmitSpanWithContextReplacesClientMetadata(t *testing.T) {
+	r := NewOTLPReceiver("otlp", OTLPConfig{IncludeMetadata: []string{"X-Tenant"}})
+	spoofed := func(id model.SpanID) *model.Span {
+		return &model.Span{TraceID: model.TraceID{Low: 1}, SpanID: id, Tags: []model.KeyValue{
+			model.String("metadata.x-tenant", "other-tenant"),
+			model.String("metadata.x-other", "x"),
+			model.String("k", "v"),
+		}}
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tenant", "acme"))
+	r.SubmitSpanWithContext(ctx, spoofed(1))
+	r.SubmitSpanWithContext(context.Background(), spoofed(2))
+
+	span := <-r.spanChan
+	assert.Equal(t, []model.KeyValue{model.String("k", "v"), model.String("metadata.x-tenant", "acme")}, span.Tags)
+
+	// Without the header, the client's value is not used either
+	span = <-r.spanChan
+	assert.Equal(t, []model.KeyValue{model.String("k", "v")}, span.Tags)
+}
+
+func TestSubmitSpanWithContextCopiesMetadata(t *testing.T) {
+	r := NewOTLPReceiver("otlp", OTLPConfig{IncludeMetadata: []string{"X-Tenant"}})
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tenant", "acme", "authorization", "secret"))
+	r.SubmitSpanWithContext(ctx, &model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 1})
+
+	span := <-r.spanChan
+	require.Len(t, span.Tags, 1)
+	assert.Equal(t, "metadata.x-tenant", span.Tags[0].Key)
+	assert.Equal(t, "acme", span.Tags[0].VStr)
+}
+
+func TestSubmitSpanNormalizes(t *testing.T) {
+	r := NewOTLPReceiver("otlp", OTLPConfig{})
+
+	r.SubmitSpan(&model.Span{SpanID: 1})
+	r.SubmitSpan(&model.Span{TraceID: model.TraceID{Low: 1}, SpanID: 2, Duration: -1})
+
+	span := <-r.spanChan
+	assert.Equal(t, model.SpanID(2), span.SpanID, "spans with a zero trace ID are dropped")
+	assert.Zero(t, span.Duration)
+	assert.Len(t, span.Warnings, 1)
+	assert.Empty(t, r.spanChan)
+}
+
+func TestSubmitSpanWithContextReplacesClientPeerIP(t *testing.T) {
+	r := NewOTLPReceiver("otlp", OTLPConfig{})
+	spoofed := func(id model.SpanID) *model.Span {
+		return &model.Span{
+			TraceID: model.TraceID{Low: 1},
+			SpanID:  id,
+			Tags:    []model.KeyValue{model.String(model.TagPeerIP, "10.9.9.9")},
+			Process: &model.Process{ServiceName: "api", Tags: []model.KeyValue{model.String(model.TagPeerIP, "10.9.9.9")}},
+		}
+	}
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP("10.1.0.5"), Port: 43122},
+	})
+	r.SubmitSpanWithContext(ctx, spoofed(1))
+	r.SubmitSpanWithContext(context.Background(), spoofed(2))
+
+	span := <-r.spanChan
+	assert.Equal(t, []model.KeyValue{model.String(model.TagPeerIP, "10.1.0.5")}, span.Tags)
+	assert.Empty(t, span.Process.Tags)
+
+	span = <-r.spanChan
+	assert.Empty(t, span.Tags)
+	assert.Empty(t, span.Process.Tags)
+}